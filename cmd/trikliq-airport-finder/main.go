@@ -1,7 +1,22 @@
 package main
 
-import "trikliq-airport-finder/internal/server"
+import (
+	"os"
+
+	"trikliq-airport-finder/internal/cli"
+	"trikliq-airport-finder/internal/server"
+)
 
 func main() {
+	// subcommands run without starting the server, anything else is a flag of server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "read":
+			os.Exit(cli.Read(os.Args[2:]))
+		case "worker":
+			os.Exit(cli.Worker(os.Args[2:]))
+		}
+	}
+
 	server.Start()
 }
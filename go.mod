@@ -3,11 +3,17 @@ module trikliq-airport-finder
 go 1.18
 
 require (
+	github.com/alicebob/miniredis/v2 v2.37.0
+	github.com/boombuler/barcode v1.1.0
 	github.com/fatih/color v1.14.1
 	github.com/gin-gonic/gin v1.8.2
 	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/google/uuid v1.3.0
+	github.com/makiuchi-d/gozxing v0.1.1
 	go.uber.org/zap v1.24.0
+	golang.org/x/net v0.5.0
+	golang.org/x/text v0.6.0
+	gopkg.in/yaml.v2 v2.4.0
 	gorm.io/gorm v1.24.5
 )
 
@@ -30,12 +36,11 @@ require (
 	github.com/onsi/gomega v1.26.0 // indirect
 	github.com/pelletier/go-toml/v2 v2.0.6 // indirect
 	github.com/ugorji/go/codec v1.2.7 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
 	go.uber.org/atomic v1.10.0 // indirect
 	go.uber.org/multierr v1.6.0 // indirect
 	golang.org/x/crypto v0.4.0 // indirect
-	golang.org/x/net v0.5.0 // indirect
 	golang.org/x/sys v0.4.0 // indirect
-	golang.org/x/text v0.6.0 // indirect
+	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	google.golang.org/protobuf v1.28.1 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
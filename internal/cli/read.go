@@ -0,0 +1,122 @@
+// Package cli has subcommands of the service binary
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/expense"
+	"trikliq-airport-finder/pkg/export"
+	"trikliq-airport-finder/pkg/logger"
+	"trikliq-airport-finder/pkg/parse"
+
+	"go.uber.org/zap"
+)
+
+// Read reads itineraries from files given as arguments and prints them the same way POST /read responds,
+// - reads standard input. -format picks any format of /read. Returns exit code, 1 if any document was rejected.
+func Read(args []string) int {
+	flags := flag.NewFlagSet("read", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: trikliq-airport-finder read [-v] [-pretty] [-format name] [-profile name] [-columns mapping] file...")
+		flags.PrintDefaults()
+	}
+
+	verbose := flags.Bool("v", false, "log progress to stderr")
+	pretty := flags.Bool("pretty", false, "indent JSON output")
+	name := flags.String("format", export.JSON.Name, "output format, one of "+strings.Join(export.Names(), ", "))
+	profile := flags.String("profile", expense.Default.Name, "columns of expense format, one of "+strings.Join(expense.ProfileNames(), ", "))
+	columns := flags.String("columns", "", "columns of expense format as field:Header,..., fields are "+strings.Join(expense.FieldNames(), ", "))
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	format, found := export.ByName(*name)
+	if !found {
+		fmt.Fprintf(flags.Output(), "unknown format %q\n", *name)
+		return 2
+	}
+	mapping, err := expense.Mapping(*profile, *columns)
+	if err != nil {
+		fmt.Fprintln(flags.Output(), err)
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	log := zap.NewNop()
+	if *verbose {
+		log = logger.Log
+	}
+
+	var (
+		result = export.NewResult()
+		now    = time.Now().UTC()
+	)
+
+	for _, path := range flags.Args() {
+		file, err := readFile(path)
+		if err != nil {
+			result.Fail(path, err)
+			continue
+		}
+
+		for _, document := range parse.Documents(file, log, parse.Options{Now: now}) {
+			result.Add(document.Name, document.Itinerary, document.Err)
+		}
+	}
+
+	// not every format has room for errors, they are reported on stderr too
+	if format.Name != export.JSON.Name {
+		for _, e := range result.Errors {
+			fmt.Fprintln(os.Stderr, e)
+		}
+	}
+
+	err = format.Encode(os.Stdout, result, export.Options{Registry: airport.Default(), Expense: mapping, Indent: *pretty})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if !result.Status {
+		return 1
+	}
+
+	return 0
+}
+
+// readFile reads file from path, or standard input if path is -
+func readFile(path string) (model.MultipartFile, error) {
+	var (
+		raw []byte
+		err error
+	)
+
+	if path == "-" {
+		raw, err = io.ReadAll(os.Stdin)
+		path = "stdin"
+	} else {
+		raw, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return model.MultipartFile{}, err
+	}
+
+	return model.MultipartFile{
+		Filename: filepath.Base(path),
+		Size:     int64(len(raw)),
+		MimeType: parse.MimeType(path, raw),
+		Content:  raw,
+	}, nil
+}
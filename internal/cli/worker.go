@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os/signal"
+	"syscall"
+
+	"trikliq-airport-finder/pkg/jobs"
+	"trikliq-airport-finder/pkg/logger"
+)
+
+// Worker runs jobs queued by POST /jobs until SIGINT or SIGTERM, jobs being run are finished first.
+// -workers overrides JOBS_WORKERS. Returns exit code.
+func Worker(args []string) int {
+	flags := flag.NewFlagSet("worker", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: trikliq-airport-finder worker [-workers n]")
+		flags.PrintDefaults()
+	}
+
+	workers := flags.Int("workers", jobs.Config.Workers, "jobs run at once")
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *workers < 1 || flags.NArg() > 0 {
+		flags.Usage()
+		return 2
+	}
+	jobs.Config.Workers = *workers
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	jobs.Run(ctx, logger.Log)
+
+	return 0
+}
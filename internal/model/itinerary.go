@@ -0,0 +1,133 @@
+package model
+
+import (
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/classify"
+	"trikliq-airport-finder/pkg/country"
+	"trikliq-airport-finder/pkg/flight"
+)
+
+type Itinerary struct {
+	Document   *classify.Document `json:"document,omitempty"`
+	Departing  []*airport.Airport `json:"departing"`
+	Arriving   []*airport.Airport `json:"arriving"`
+	Segments   []Segment          `json:"segments"`
+	Metros     []Metro            `json:"metros,omitempty"`
+	Countries  []*country.Country `json:"countries"`
+	Trip       *Trip              `json:"trip,omitempty"`
+	Passengers []Passenger        `json:"passengers,omitempty"`
+	Receipt    *Receipt           `json:"receipt,omitempty"`
+	Conflicts  []Conflict         `json:"conflicts,omitempty"`
+	Provenance []Provenance       `json:"provenance,omitempty"`
+}
+
+type Segment struct {
+	Departure         string              `json:"departure"`
+	Arrival           string              `json:"arrival"`
+	FlightNumber      string              `json:"flightNumber,omitempty"`
+	Reservation       string              `json:"reservation,omitempty"`
+	DepartureTerminal string              `json:"departureTerminal,omitempty"`
+	ArrivalTerminal   string              `json:"arrivalTerminal,omitempty"`
+	Gate              string              `json:"gate,omitempty"`
+	Classification    string              `json:"classification,omitempty"`
+	DepartureCountry  string              `json:"departureCountry,omitempty"`
+	ArrivalCountry    string              `json:"arrivalCountry,omitempty"`
+	Date              string              `json:"date,omitempty"`
+	YearGuessed       bool                `json:"yearGuessed,omitempty"`
+	DepartureTime     string              `json:"departureTime,omitempty"`
+	ArrivalTime       string              `json:"arrivalTime,omitempty"`
+	BlockMinutes      int                 `json:"blockMinutes,omitempty"`
+	Overnight         bool                `json:"overnight,omitempty"`
+	DistanceKm        float64             `json:"distanceKm,omitempty"`
+	DistanceMi        float64             `json:"distanceMi,omitempty"`
+	Plausibility      flight.Plausibility `json:"plausibility"`
+	CO2               *flight.Emission    `json:"co2,omitempty"`
+	Source            string              `json:"source"`
+	Confidence        float64             `json:"confidence"`
+}
+
+type Metro struct {
+	Code         string   `json:"code"`
+	Name         string   `json:"name"`
+	Airport      string   `json:"airport,omitempty"`
+	Alternatives []string `json:"alternatives,omitempty"`
+	Reason       string   `json:"reason"`
+}
+
+type Trip struct {
+	Type     string    `json:"type"`
+	Journeys []Journey `json:"journeys"`
+}
+
+type Journey struct {
+	Origin      string       `json:"origin"`
+	Destination string       `json:"destination"`
+	Segments    []int        `json:"segments"`
+	Connections []Connection `json:"connections,omitempty"`
+}
+
+type Connection struct {
+	Airport        string `json:"airport"`
+	LayoverMinutes int    `json:"layoverMinutes,omitempty"`
+	Overnight      bool   `json:"overnight,omitempty"`
+}
+
+type Passenger struct {
+	Name          string             `json:"name"`
+	Title         string             `json:"title,omitempty"`
+	Type          string             `json:"type"`
+	FrequentFlyer []FrequentFlyer    `json:"frequentFlyer,omitempty"`
+	Segments      []PassengerSegment `json:"segments,omitempty"`
+}
+
+type FrequentFlyer struct {
+	Program string `json:"program"`
+	Number  string `json:"number"`
+}
+
+type PassengerSegment struct {
+	Segment      int    `json:"segment"`
+	Cabin        string `json:"cabin,omitempty"`
+	BookingClass string `json:"bookingClass,omitempty"`
+	Seat         string `json:"seat,omitempty"`
+	Baggage      string `json:"baggage,omitempty"`
+}
+
+// Receipt is payment of tickets of itinerary, amounts are in Currency. Fares paid with miles
+// have no Fare, only Miles.
+type Receipt struct {
+	Reservation   string   `json:"reservation,omitempty"`
+	TicketNumbers []string `json:"ticketNumbers,omitempty"`
+	IssueDate     string   `json:"issueDate,omitempty"`
+	Currency      string   `json:"currency,omitempty"`
+	Fare          float64  `json:"fare,omitempty"`
+	Taxes         float64  `json:"taxes,omitempty"`
+	Total         float64  `json:"total,omitempty"`
+	Miles         int      `json:"miles,omitempty"`
+	Charges       []Charge `json:"charges,omitempty"`
+}
+
+// Charge is a tax, fee, surcharge or paid baggage itemised on receipt, Type is tax or baggage
+type Charge struct {
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	Amount float64 `json:"amount"`
+}
+
+// Conflict is a field sources of itinerary disagree on, value of the more reliable source is kept
+type Conflict struct {
+	Segment        int    `json:"segment"`
+	Field          string `json:"field"`
+	Value          string `json:"value"`
+	Source         string `json:"source"`
+	Rejected       string `json:"rejected"`
+	RejectedSource string `json:"rejectedSource"`
+}
+
+// Provenance names part of document a value of segment was read from, e.g. body or attachment of email
+type Provenance struct {
+	Segment int    `json:"segment"`
+	Field   string `json:"field"`
+	Source  string `json:"source"`
+	Part    string `json:"part"`
+}
@@ -0,0 +1,8 @@
+package model
+
+type Page struct {
+	Total int `json:"total"`
+	Page  int `json:"page"`
+	Limit int `json:"limit"`
+	Items any `json:"items"`
+}
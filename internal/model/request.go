@@ -7,9 +7,10 @@ type Request struct {
 }
 
 type Response struct {
-	Status bool     `json:"status"`
-	Errors []string `json:"errors"`
-	Data   any      `json:"data,omitempty"`
+	Status  bool     `json:"status"`
+	Errors  []string `json:"errors"`
+	Data    any      `json:"data,omitempty"`
+	Summary any      `json:"summary,omitempty"`
 }
 
 type MultipartFile struct {
@@ -0,0 +1,245 @@
+package airports
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/internal/route/fail"
+	"trikliq-airport-finder/internal/server/router"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/crypto"
+	"trikliq-airport-finder/pkg/geo"
+	"trikliq-airport-finder/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+
+	// defaultRadius of nearby search in kilometres
+	defaultRadius = 100.0
+	// maxRadius is half of circumference of the Earth, every point is within it
+	maxRadius = math.Pi * geo.EarthRadiusKm
+)
+
+// notModified sets ETag of response and reports if client already has the same representation
+func notModified(ctx *gin.Context, registry *airport.Registry) bool {
+	etag := fmt.Sprintf(`"%s"`, crypto.SHA256(registry.ETag+ctx.Request.URL.RequestURI()))
+
+	// dataset is static, allow caches to keep it as long as they revalidate
+	ctx.Header("ETag", etag)
+	ctx.Header("cache-control", "public, no-cache")
+	ctx.Writer.Header().Del("expires")
+
+	if ctx.GetHeader("If-None-Match") == etag {
+		ctx.Status(304)
+		return true
+	}
+
+	return false
+}
+
+// pagination reads page and limit query parameters
+func pagination(ctx *gin.Context) (page, limit int) {
+	page, _ = strconv.Atoi(ctx.Query("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit, _ = strconv.Atoi(ctx.Query("limit"))
+	if limit < 1 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	return
+}
+
+func LookupHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+		registry = airport.Default()
+		code     = ctx.Param("code")
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+		zap.String("code", code),
+	))
+
+	if notModified(ctx, registry) {
+		return
+	}
+
+	found, ok := registry.Lookup(code)
+	if !ok {
+		log.Debug("airport not found")
+		fail.ReturnErrorCode(ctx, 404, response, "airport not found", log)
+		return
+	}
+
+	response.Status = true
+	response.Data = found
+	ctx.JSON(200, response)
+}
+
+func SearchHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+		registry = airport.Default()
+		query    = airport.Query{
+			City:    ctx.Query("city"),
+			Country: ctx.Query("country"),
+			State:   ctx.Query("state"),
+			Text:    ctx.Query("q"),
+		}
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+		zap.Any("query", query),
+	))
+
+	if query == (airport.Query{}) {
+		fail.ReturnError(ctx, response, "one of city, country, state or q is required", log)
+		return
+	}
+
+	if notModified(ctx, registry) {
+		return
+	}
+
+	page, limit := pagination(ctx)
+	results := registry.Search(query)
+
+	start := (page - 1) * limit
+	if start > len(results) {
+		start = len(results)
+	}
+	end := start + limit
+	if end > len(results) {
+		end = len(results)
+	}
+
+	log.Debug("airports searched",
+		zap.Int("total", len(results)),
+	)
+
+	response.Status = true
+	response.Data = model.Page{
+		Total: len(results),
+		Page:  page,
+		Limit: limit,
+		Items: results[start:end],
+	}
+	ctx.JSON(200, response)
+}
+
+func SuggestHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+		registry = airport.Default()
+		text     = ctx.Query("q")
+	)
+
+	if text == "" {
+		requestID, _ := ctx.Get("id")
+
+		log := logger.Log.WithOptions(zap.Fields(
+			zap.Any("requestID", requestID),
+		))
+
+		fail.ReturnError(ctx, response, "q is required", log)
+		return
+	}
+
+	if notModified(ctx, registry) {
+		return
+	}
+
+	limit, _ := strconv.Atoi(ctx.Query("limit"))
+	if limit < 1 {
+		limit = 10
+	}
+
+	response.Status = true
+	response.Data = registry.Suggest(text, limit)
+	ctx.JSON(200, response)
+}
+
+func NearbyHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+		registry = airport.Default()
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+	))
+
+	lat, err := strconv.ParseFloat(ctx.Query("lat"), 64)
+	if err != nil {
+		fail.ReturnError(ctx, response, "lat is not a number", log)
+		return
+	}
+
+	lon, err := strconv.ParseFloat(ctx.Query("lon"), 64)
+	if err != nil {
+		fail.ReturnError(ctx, response, "lon is not a number", log)
+		return
+	}
+
+	if !geo.ValidCoordinates(lat, lon) {
+		fail.ReturnError(ctx, response, "lat or lon is out of range", log)
+		return
+	}
+
+	radius := defaultRadius
+	if ctx.Query("radius") != "" {
+		radius, err = strconv.ParseFloat(ctx.Query("radius"), 64)
+		// comparisons of NaN are all false, it has to be checked on its own
+		if err != nil || math.IsNaN(radius) || math.IsInf(radius, 0) || radius <= 0 || radius > maxRadius {
+			fail.ReturnError(ctx, response, fmt.Sprintf("radius has to be a positive number of kilometres up to %.0f", maxRadius), log)
+			return
+		}
+	}
+
+	if !registry.HasCoordinates() {
+		fail.ReturnErrorCode(ctx, 503, response, airport.ErrNoCoordinates.Error(), log)
+		return
+	}
+
+	if notModified(ctx, registry) {
+		return
+	}
+
+	_, limit := pagination(ctx)
+
+	response.Status = true
+	response.Data = registry.Nearby(lat, lon, radius, limit)
+	ctx.JSON(200, response)
+}
+
+func init() {
+	router.Router.Handle("GET", "/airports", SearchHandler)
+	router.Router.Handle("GET", "/airports/suggest", SuggestHandler)
+	router.Router.Handle("GET", "/airports/nearby", NearbyHandler)
+	router.Router.Handle("GET", "/airports/:code", LookupHandler)
+}
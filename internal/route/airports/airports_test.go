@@ -0,0 +1,23 @@
+package airports
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNearbyRadius(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/airports/nearby", NearbyHandler)
+
+	for _, radius := range []string{"NaN", "nan", "Inf", "-Inf", "0", "-5", "1e9", "20016", "ten"} {
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, httptest.NewRequest("GET", "/airports/nearby?lat=1.36&lon=103.99&radius="+radius, nil))
+
+		if recorder.Code != 400 {
+			t.Errorf("radius %s: got status %d, want 400", radius, recorder.Code)
+		}
+	}
+}
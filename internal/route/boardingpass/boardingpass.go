@@ -0,0 +1,102 @@
+package boardingpass
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/internal/route/fail"
+	"trikliq-airport-finder/internal/server/router"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/bcbp"
+	"trikliq-airport-finder/pkg/logger"
+	"trikliq-airport-finder/pkg/parse"
+	"trikliq-airport-finder/pkg/trip"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxLength of request body, BCBP with four legs and security data is well below it
+const maxLength = 4096
+
+// Decoded is a boarding pass together with itinerary it maps to
+type Decoded struct {
+	BoardingPass *bcbp.BoardingPass `json:"boardingPass"`
+	Itinerary    model.Itinerary    `json:"itinerary"`
+}
+
+// DecodeHandler decodes BCBP string sent either as {"data": "M1..."} or as plain text
+func DecodeHandler(ctx *gin.Context) {
+
+	var (
+		request  = model.Request{}
+		response = model.Response{}
+		data     string
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+	))
+
+	switch ctx.ContentType() {
+	case "application/json":
+		ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxLength)
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			fail.ReturnError(ctx, response, err.Error(), log)
+			return
+		}
+
+		data, _ = request.Data.(string)
+
+	case "text/plain":
+		raw, err := io.ReadAll(io.LimitReader(ctx.Request.Body, maxLength))
+		if err != nil {
+			log.Error("failed to read body",
+				zap.Error(err),
+			)
+			fail.ReturnError(ctx, response, err.Error(), log)
+			return
+		}
+
+		data = string(raw)
+
+	default:
+		fail.ReturnError(ctx, response, "content-type is not application/json or text/plain", log)
+		return
+	}
+
+	if strings.TrimSpace(data) == "" {
+		fail.ReturnError(ctx, response, "data is required", log)
+		return
+	}
+
+	pass, err := bcbp.Decode(data)
+	if err != nil {
+		log.Debug("invalid boarding pass",
+			zap.Error(err),
+		)
+		fail.ReturnError(ctx, response, err.Error(), log)
+		return
+	}
+
+	registry := airport.Default()
+	itinerary := parse.FromBoardingPasses([]*bcbp.BoardingPass{pass}, registry, time.Now().UTC())
+	parse.Enrich(&itinerary, registry)
+	trip.Assemble(&itinerary, registry)
+
+	response.Status = true
+	response.Data = Decoded{
+		BoardingPass: pass,
+		Itinerary:    itinerary,
+	}
+	ctx.JSON(200, response)
+}
+
+func init() {
+	router.Router.Handle("POST", "/bcbp/decode", DecodeHandler)
+}
@@ -19,6 +19,11 @@ func SystemError(requestID any) string {
 }
 
 func ReturnError(ctx *gin.Context, response model.Response, errMessage string, log *zap.Logger) {
+	ReturnErrorCode(ctx, 400, response, errMessage, log)
+}
+
+// ReturnErrorCode is ReturnError with custom HTTP status code
+func ReturnErrorCode(ctx *gin.Context, code int, response model.Response, errMessage string, log *zap.Logger) {
 	var (
 		raw []byte
 		err error
@@ -35,9 +40,9 @@ func ReturnError(ctx *gin.Context, response model.Response, errMessage string, l
 			zap.Error(err),
 		)
 
-		ctx.JSON(400, response)
+		ctx.JSON(code, response)
 		return
 	}
 
-	ctx.Data(400, "application/json", raw)
+	ctx.Data(code, "application/json", raw)
 }
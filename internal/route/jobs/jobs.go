@@ -0,0 +1,339 @@
+// Package jobs reads uploads in background, for uploads which take longer than a request may
+package jobs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/internal/route/fail"
+	"trikliq-airport-finder/internal/server/router"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/expense"
+	"trikliq-airport-finder/pkg/export"
+	"trikliq-airport-finder/pkg/jobs"
+	"trikliq-airport-finder/pkg/logger"
+	"trikliq-airport-finder/pkg/parse"
+	"trikliq-airport-finder/pkg/webhook"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Status is job with itineraries of its result once it succeeded
+type Status struct {
+	jobs.Job
+	Result *model.Response `json:"result,omitempty"`
+}
+
+// CreateHandler queues uploaded files for reading and returns the job. Form field or ?callback= is URL the job is
+// posted to once it succeeds or fails.
+func CreateHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+	))
+
+	if ctx.ContentType() != "multipart/form-data" {
+		fail.ReturnError(ctx, response, "content-type is not multipart/form-data", log)
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		log.Error("form not found",
+			zap.Error(err),
+		)
+		fail.ReturnError(ctx, response, err.Error(), log)
+		return
+	}
+
+	if len(form.File) == 0 {
+		fail.ReturnError(ctx, response, "no files found in form", log)
+		return
+	}
+
+	callback := ctx.Query("callback")
+	if values := form.Value["callback"]; len(values) > 0 {
+		callback = values[0]
+	}
+	if callback != "" {
+		if err := webhook.Validate(callback); err != nil {
+			fail.ReturnError(ctx, response, err.Error(), log)
+			return
+		}
+	}
+
+	files := parse.FormFiles(form, log)
+	if len(files) == 0 {
+		fail.ReturnError(ctx, response, "no files could be read from form", log)
+		return
+	}
+
+	job, err := jobs.Default().Create(files, callback)
+	if err != nil {
+		log.Error("failed to create job",
+			zap.Error(err),
+		)
+		fail.ReturnErrorCode(ctx, 503, response, fail.SystemError(requestID), log)
+		return
+	}
+
+	log.Info("job created",
+		zap.String("jobID", job.ID),
+		zap.Int("files", len(files)),
+	)
+
+	ctx.Header("Location", "/jobs/"+job.ID)
+	response.Status = true
+	response.Data = Status{Job: job}
+	ctx.JSON(202, response)
+}
+
+// GetHandler returns job, itineraries of succeeded jobs are returned with it
+func GetHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+		store    = jobs.Default()
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+		zap.String("jobID", ctx.Param("id")),
+	))
+
+	job, ok := get(ctx, store, response, log)
+	if !ok {
+		return
+	}
+
+	status := Status{Job: job}
+	if job.Status == jobs.StatusSucceeded {
+		result, err := store.Result(job.ID)
+		if err != nil {
+			log.Error("failed to get result of job",
+				zap.Error(err),
+			)
+			fail.ReturnErrorCode(ctx, 503, response, fail.SystemError(requestID), log)
+			return
+		}
+
+		read := result.Response()
+		status.Result = &read
+	}
+
+	response.Status = true
+	response.Data = status
+	ctx.JSON(200, response)
+}
+
+// ResultHandler writes result of succeeded job in format of ?format= or Accept header, like /read does
+func ResultHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+		store    = jobs.Default()
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+		zap.String("jobID", ctx.Param("id")),
+	))
+
+	ctx.Header("Vary", "Accept")
+
+	format, found := export.Negotiate(ctx.GetHeader("Accept"))
+	if name := ctx.Query("format"); name != "" {
+		if format, found = export.ByName(name); !found {
+			fail.ReturnError(ctx, response, fmt.Sprintf("unknown format %q, formats are %s", name, strings.Join(export.Names(), ", ")), log)
+			return
+		}
+	}
+	if !found {
+		fail.ReturnErrorCode(ctx, 406, response, fmt.Sprintf("none of accepted media types can be written, formats are %s", strings.Join(export.Names(), ", ")), log)
+		return
+	}
+
+	mapping, err := expense.Mapping(ctx.Query("profile"), ctx.Query("columns"))
+	if err != nil {
+		fail.ReturnError(ctx, response, err.Error(), log)
+		return
+	}
+
+	job, ok := get(ctx, store, response, log)
+	if !ok {
+		return
+	}
+	if job.Status != jobs.StatusSucceeded {
+		fail.ReturnErrorCode(ctx, 409, response, fmt.Sprintf("job is %s, only succeeded jobs have a result", job.Status), log)
+		return
+	}
+
+	result, err := store.Result(job.ID)
+	if err != nil {
+		log.Error("failed to get result of job",
+			zap.Error(err),
+		)
+		fail.ReturnErrorCode(ctx, 503, response, fail.SystemError(requestID), log)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := format.Encode(&b, result, export.Options{Registry: airport.Default(), Expense: mapping}); err != nil {
+		log.Error("failed to write result",
+			zap.String("format", format.Name),
+			zap.Error(err),
+		)
+		if errors.Is(err, airport.ErrNoCoordinates) {
+			fail.ReturnErrorCode(ctx, 422, response, err.Error(), log)
+			return
+		}
+		fail.ReturnError(ctx, response, err.Error(), log)
+		return
+	}
+
+	if format.Attachment {
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="itinerary.%s"`, format.Extension))
+	}
+	ctx.Data(200, format.ContentType(), b.Bytes())
+}
+
+// CancelHandler cancels job, running jobs stop before their next file
+func CancelHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+		zap.String("jobID", ctx.Param("id")),
+	))
+
+	job, err := jobs.Default().Cancel(ctx.Param("id"))
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		fail.ReturnErrorCode(ctx, 404, response, err.Error(), log)
+		return
+	case errors.Is(err, jobs.ErrFinished):
+		fail.ReturnErrorCode(ctx, 409, response, fmt.Sprintf("job is already %s", job.Status), log)
+		return
+	case err != nil:
+		log.Error("failed to cancel job",
+			zap.Error(err),
+		)
+		fail.ReturnErrorCode(ctx, 503, response, fail.SystemError(requestID), log)
+		return
+	}
+
+	log.Info("job cancelled",
+		zap.String("status", job.Status),
+	)
+
+	response.Status = true
+	response.Data = Status{Job: job}
+	ctx.JSON(202, response)
+}
+
+// DeliveriesHandler returns attempts to post callback of job, oldest first
+func DeliveriesHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+		zap.String("jobID", ctx.Param("id")),
+	))
+
+	deliveries, err := jobs.Default().Deliveries(ctx.Param("id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		fail.ReturnErrorCode(ctx, 404, response, err.Error(), log)
+		return
+	}
+	if err != nil {
+		log.Error("failed to get deliveries of job",
+			zap.Error(err),
+		)
+		fail.ReturnErrorCode(ctx, 503, response, fail.SystemError(requestID), log)
+		return
+	}
+
+	response.Status = true
+	response.Data = deliveries
+	ctx.JSON(200, response)
+}
+
+// WorkersHandler returns heartbeats of running workers
+func WorkersHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+	))
+
+	workers, err := jobs.Default().Workers()
+	if err != nil {
+		log.Error("failed to get workers",
+			zap.Error(err),
+		)
+		fail.ReturnErrorCode(ctx, 503, response, fail.SystemError(requestID), log)
+		return
+	}
+
+	response.Status = true
+	response.Data = workers
+	ctx.JSON(200, response)
+}
+
+// get returns job of :id, errors are responded to
+func get(ctx *gin.Context, store *jobs.Store, response model.Response, log *zap.Logger) (jobs.Job, bool) {
+	requestID, _ := ctx.Get("id")
+
+	job, err := store.Get(ctx.Param("id"))
+	if errors.Is(err, jobs.ErrNotFound) {
+		fail.ReturnErrorCode(ctx, 404, response, err.Error(), log)
+		return job, false
+	}
+	if err != nil {
+		log.Error("failed to get job",
+			zap.Error(err),
+		)
+		fail.ReturnErrorCode(ctx, 503, response, fail.SystemError(requestID), log)
+		return job, false
+	}
+
+	return job, true
+}
+
+func init() {
+	router.Router.Handle("POST", "/jobs", CreateHandler)
+	router.Router.Handle("GET", "/jobs/workers", WorkersHandler)
+	router.Router.Handle("GET", "/jobs/:id", GetHandler)
+	router.Router.Handle("GET", "/jobs/:id/result", ResultHandler)
+	router.Router.Handle("GET", "/jobs/:id/deliveries", DeliveriesHandler)
+	router.Router.Handle("DELETE", "/jobs/:id", CancelHandler)
+}
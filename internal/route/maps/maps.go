@@ -0,0 +1,80 @@
+package maps
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/internal/route/fail"
+	"trikliq-airport-finder/internal/server/router"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/logger"
+	"trikliq-airport-finder/pkg/maps"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxCodes of a route, round the world trips have fewer stops
+const maxCodes = 16
+
+// codes are separated by commas, dashes or spaces, e.g. SIN-DPS-SIN
+var separatorRegex = regexp.MustCompile(`[\s,\-]+`)
+
+// RouteHandler draws route through airports of ?codes= as SVG map
+func RouteHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+		registry = airport.Default()
+		codes    = make([]string, 0)
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+		zap.String("codes", ctx.Query("codes")),
+	))
+
+	for _, code := range separatorRegex.Split(strings.ToUpper(ctx.Query("codes")), -1) {
+		if code != "" {
+			codes = append(codes, code)
+		}
+	}
+
+	if len(codes) < 2 || len(codes) > maxCodes {
+		fail.ReturnError(ctx, response, fmt.Sprintf("codes must list 2 to %d IATA codes, e.g. codes=SIN,DPS,SIN", maxCodes), log)
+		return
+	}
+
+	for _, code := range codes {
+		if _, found := registry.IATA(code); !found {
+			fail.ReturnErrorCode(ctx, 404, response, fmt.Sprintf("airport %s not found", code), log)
+			return
+		}
+	}
+
+	m := maps.Codes(strings.Join(codes, "-"), codes, registry)
+	if err := m.Complete(); err != nil {
+		fail.ReturnErrorCode(ctx, 422, response, err.Error(), log)
+		return
+	}
+
+	var b bytes.Buffer
+	if err := m.Encode(&b); err != nil {
+		log.Error("failed to draw map",
+			zap.Error(err),
+		)
+		fail.ReturnErrorCode(ctx, 500, response, fail.SystemError(requestID), log)
+		return
+	}
+
+	ctx.Data(200, maps.MimeType+"; charset=utf-8", b.Bytes())
+}
+
+func init() {
+	router.Router.Handle("GET", "/maps/route", RouteHandler)
+}
@@ -1,16 +1,29 @@
 package read
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
 	"trikliq-airport-finder/internal/model"
 	"trikliq-airport-finder/internal/route/fail"
 	"trikliq-airport-finder/internal/server/router"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/expense"
+	"trikliq-airport-finder/pkg/export"
 	"trikliq-airport-finder/pkg/logger"
 	"trikliq-airport-finder/pkg/parse"
+	"trikliq-airport-finder/pkg/pool"
 
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
+// ReadHandler reads itineraries from uploaded files, they are written in format of ?format= or Accept header
 func ReadHandler(ctx *gin.Context) {
 
 	var (
@@ -26,6 +39,28 @@ func ReadHandler(ctx *gin.Context) {
 
 	log.Info("read started")
 
+	// responses of the same URL differ by Accept
+	ctx.Header("Vary", "Accept")
+
+	format, found := export.Negotiate(ctx.GetHeader("Accept"))
+	if name := ctx.Query("format"); name != "" {
+		if format, found = export.ByName(name); !found {
+			fail.ReturnError(ctx, response, fmt.Sprintf("unknown format %q, formats are %s", name, strings.Join(export.Names(), ", ")), log)
+			return
+		}
+	}
+	if !found {
+		fail.ReturnErrorCode(ctx, 406, response, fmt.Sprintf("none of accepted media types can be written, formats are %s", strings.Join(export.Names(), ", ")), log)
+		return
+	}
+
+	// expense format takes columns of a profile, or of ?columns=total:Amount,currency:Currency
+	mapping, err := expense.Mapping(ctx.Query("profile"), ctx.Query("columns"))
+	if err != nil {
+		fail.ReturnError(ctx, response, err.Error(), log)
+		return
+	}
+
 	switch ctx.ContentType() {
 	case "multipart/form-data":
 
@@ -48,36 +83,96 @@ func ReadHandler(ctx *gin.Context) {
 			return
 		}
 
-		files := make([]model.MultipartFile, 0)
-		for _, fileHeaders := range form.File {
-			rawFiles, err := parse.ReadMultipartFiles(fileHeaders)
-			if err != nil {
-				log.Error("error while reading multipart file(s)",
-					zap.Error(err),
-				)
-				continue
+		files := parse.FormFiles(form, log)
+
+		// files are read at once by the pool shared with other requests, documents keep order of files
+		options := parse.Options{Now: time.Now().UTC()}
+		documents := make([][]parse.Document, len(files))
+		tasks := make([]func(), 0, len(files))
+		for i, file := range files {
+			i, file := i, file
+			tasks = append(tasks, func() {
+				documents[i] = parse.Documents(file, log.With(zap.String("filename", file.Filename)), options)
+			})
+		}
+
+		workers := pool.Default()
+		err = workers.Run(ctx.Request.Context(), tasks...)
+		if errors.Is(err, pool.ErrTooLarge) {
+			log.Warn("read refused",
+				zap.Int("files", len(files)),
+				zap.Error(err),
+			)
+
+			fail.ReturnErrorCode(ctx, 413, response, err.Error(), log)
+			return
+		}
+		if errors.Is(err, pool.ErrSaturated) {
+			retryAfter := workers.RetryAfter()
+			log.Warn("read refused",
+				zap.Int("files", len(files)),
+				zap.Duration("retryAfter", retryAfter),
+				zap.Error(err),
+			)
+
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			fail.ReturnErrorCode(ctx, 429, response, err.Error(), log)
+			return
+		}
+		if err != nil {
+			log.Warn("read cancelled by client",
+				zap.Error(err),
+			)
+			return
+		}
+
+		result := export.NewResult()
+		for _, read := range documents {
+			// mailboxes hold a document per message
+			for _, document := range read {
+				if document.Err != nil {
+					log.Warn("document rejected",
+						zap.String("filename", document.Name),
+						zap.Error(document.Err),
+					)
+				}
+
+				// rejected documents are still listed with their classification
+				result.Add(document.Name, document.Itinerary, document.Err)
 			}
-			files = append(files, rawFiles...)
 		}
 
-		result := make(map[string]any, 0)
+		var b bytes.Buffer
+		if err := format.Encode(&b, result, export.Options{Registry: airport.Default(), Expense: mapping}); err != nil {
+			log.Error("failed to write result",
+				zap.String("format", format.Name),
+				zap.Error(err),
+			)
 
-		for _, file := range files {
-			result[file.Filename] = parse.Parse(file.Content, log)
+			response.Errors = result.Errors
+			if errors.Is(err, airport.ErrNoCoordinates) {
+				fail.ReturnErrorCode(ctx, 422, response, err.Error(), log)
+				return
+			}
+			fail.ReturnError(ctx, response, err.Error(), log)
+			return
 		}
 
-		response.Data = result
+		log.Info("read finished",
+			zap.String("format", format.Name),
+			zap.Int("documents", len(result.Documents)),
+		)
+
+		if format.Attachment {
+			ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="itinerary.%s"`, format.Extension))
+		}
+		ctx.Data(200, format.ContentType(), b.Bytes())
 
 	default:
 
 		fail.ReturnError(ctx, response, "content-type is not multipart/form-data", log)
 		return
 	}
-
-	log.Info("read finished")
-
-	response.Status = true
-	ctx.JSON(200, response)
 }
 
 func init() {
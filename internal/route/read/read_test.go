@@ -0,0 +1,113 @@
+package read
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"trikliq-airport-finder/pkg/parse"
+	"trikliq-airport-finder/pkg/pool"
+
+	"github.com/gin-gonic/gin"
+)
+
+// upload posts n HTML files to handler at path and returns its response
+func upload(t *testing.T, path string, handler gin.HandlerFunc, n int) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for i := 0; i < n; i++ {
+		file, err := form.CreateFormFile("file", "booking.html")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Write([]byte("<p>SQ 938 SIN DPS</p>"))
+	}
+	form.Close()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.POST(path, handler)
+
+	request := httptest.NewRequest("POST", path, &body)
+	request.Header.Set("Content-Type", form.FormDataContentType())
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestReadSaturated(t *testing.T) {
+	pool.Config = pool.PoolConfig{Size: 1, Depth: 1}
+	workers := pool.Default()
+
+	// a read of another request keeps the only worker busy
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		done    = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		workers.Do(context.Background(), func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+	defer func() {
+		close(release)
+		<-done
+	}()
+
+	recorder := upload(t, "/read", ReadHandler, 2)
+	if recorder.Code != 429 {
+		t.Errorf("got status %d of batch while queue is full, want 429", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") == "" {
+		t.Error("got no Retry-After")
+	}
+
+	if recorder = upload(t, "/read", ReadHandler, 3); recorder.Code != 413 {
+		t.Errorf("got status %d of batch larger than queue, want 413", recorder.Code)
+	}
+	if recorder = upload(t, "/read/stream", StreamHandler, 2); recorder.Code != 429 {
+		t.Errorf("got status %d of stream while queue is full, want 429", recorder.Code)
+	}
+	if recorder = upload(t, "/read/stream", StreamHandler, 3); recorder.Code != 413 {
+		t.Errorf("got status %d of stream larger than queue, want 413", recorder.Code)
+	}
+}
+
+func TestStream(t *testing.T) {
+	// airports are read from data of the repository
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join("..", "..", "..")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(dir)
+	})
+
+	recorder := upload(t, "/read/stream", StreamHandler, 2)
+	if recorder.Code != 200 {
+		t.Fatalf("got status %d", recorder.Code)
+	}
+
+	body := recorder.Body.String()
+	if n := strings.Count(body, "event:"+parse.StageReceived+"\n"); n != 2 {
+		t.Errorf("got %d received events, want one per file", n)
+	}
+	if last := strings.LastIndex(body, "event:"); !strings.HasPrefix(body[last:], "event:result\n") {
+		t.Errorf("got last event %q, want result", body[last:])
+	}
+}
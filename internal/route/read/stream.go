@@ -0,0 +1,228 @@
+package read
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/internal/route/fail"
+	"trikliq-airport-finder/internal/server/router"
+	"trikliq-airport-finder/pkg/export"
+	"trikliq-airport-finder/pkg/logger"
+	"trikliq-airport-finder/pkg/parse"
+	"trikliq-airport-finder/pkg/pool"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// eventTimeout is how long an event may take to write, the stream itself has no limit
+	eventTimeout = 5 * time.Second
+	// eventBuffer is how many events reads may get ahead of the client before they wait for it
+	eventBuffer = 64
+)
+
+// event is a server-sent event waiting to be written by the handler
+type event struct {
+	name string
+	data any
+}
+
+// StreamHandler reads uploaded files like ReadHandler and streams progress as server-sent events, an event per
+// file and stage: received, extracted, candidates, then done or error per document with its itinerary. The last
+// event is result with the response /read would give. Files are read at once by the shared pool, so events of
+// files interleave. Reads only queue their events, the handler writes them.
+func StreamHandler(ctx *gin.Context) {
+
+	var (
+		response = model.Response{}
+	)
+
+	requestID, _ := ctx.Get("id")
+
+	log := logger.Log.WithOptions(zap.Fields(
+		zap.Any("requestID", requestID),
+	))
+
+	if ctx.ContentType() != "multipart/form-data" {
+		fail.ReturnError(ctx, response, "content-type is not multipart/form-data", log)
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		log.Error("form not found",
+			zap.Error(err),
+		)
+		fail.ReturnError(ctx, response, err.Error(), log)
+		return
+	}
+
+	if len(form.File) == 0 {
+		fail.ReturnError(ctx, response, "no files found in form", log)
+		return
+	}
+
+	files := parse.FormFiles(form, log)
+
+	log.Info("read stream started",
+		zap.Int("files", len(files)),
+	)
+
+	// proxies would buffer events until the stream ends
+	ctx.Header("X-Accel-Buffering", "no")
+
+	send := func(name string, data any) {
+		// the server times out writes of whole responses, every event gets time of its own
+		if err := router.ExtendWrite(ctx.Request, eventTimeout); err != nil {
+			log.Debug("write deadline not extended",
+				zap.Error(err),
+			)
+		}
+
+		ctx.SSEvent(name, data)
+		ctx.Writer.Flush()
+	}
+
+	var (
+		workers   = pool.Default()
+		now       = time.Now().UTC()
+		events    = make(chan event, eventBuffer)
+		documents = make([][]parse.Document, len(files))
+		tasks     = make([]func(), 0, len(files))
+	)
+
+	// a failed write cancels the request, reads waiting for a stalled client stop with it
+	queue := func(name string, data any) {
+		select {
+		case events <- event{name: name, data: data}:
+		case <-ctx.Request.Context().Done():
+		}
+	}
+
+	for i, file := range files {
+		i, file := i, file
+
+		progress := parse.Observer(func(e parse.Event) {
+			data := map[string]any{"file": file.Filename}
+			for key, value := range e.Fields {
+				data[key] = value
+			}
+			queue(e.Stage, data)
+		})
+
+		tasks = append(tasks, func() {
+			queue(parse.StageReceived, map[string]any{
+				"file":     file.Filename,
+				"index":    i,
+				"files":    len(files),
+				"size":     file.Size,
+				"mimeType": file.MimeType,
+			})
+
+			documents[i] = parse.Documents(file, log.With(zap.String("filename", file.Filename)), parse.Options{Now: now, Progress: progress})
+
+			for _, document := range documents[i] {
+				data := map[string]any{
+					"file":      file.Filename,
+					"document":  document.Name,
+					"itinerary": document.Itinerary,
+				}
+				if document.Err != nil {
+					data["error"] = document.Err.Error()
+					queue(parse.StageError, data)
+					continue
+				}
+				queue(parse.StageDone, data)
+			}
+		})
+	}
+
+	// panics of reads are raised again by the handler, recovery of the router handles them
+	type outcome struct {
+		err   error
+		fault any
+	}
+	finished := make(chan outcome, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				finished <- outcome{fault: r}
+			}
+		}()
+
+		finished <- outcome{err: workers.Run(ctx.Request.Context(), tasks...)}
+	}()
+
+	var done outcome
+	for running := true; running; {
+		select {
+		case e := <-events:
+			send(e.name, e.data)
+		case done = <-finished:
+			running = false
+		}
+	}
+	// reads are done, events they queued last are still buffered
+	for len(events) > 0 {
+		e := <-events
+		send(e.name, e.data)
+	}
+
+	if done.fault != nil {
+		panic(done.fault)
+	}
+
+	// refused reads queued no events, nothing was written yet
+	if errors.Is(done.err, pool.ErrTooLarge) {
+		log.Warn("read stream refused",
+			zap.Int("files", len(files)),
+			zap.Error(done.err),
+		)
+
+		fail.ReturnErrorCode(ctx, 413, response, done.err.Error(), log)
+		return
+	}
+	if errors.Is(done.err, pool.ErrSaturated) {
+		retryAfter := workers.RetryAfter()
+		log.Warn("read stream refused",
+			zap.Duration("retryAfter", retryAfter),
+			zap.Error(done.err),
+		)
+
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		fail.ReturnErrorCode(ctx, 429, response, done.err.Error(), log)
+		return
+	}
+	if done.err != nil {
+		log.Warn("read stream cancelled by client",
+			zap.Error(done.err),
+		)
+		return
+	}
+
+	result := export.NewResult()
+	for _, read := range documents {
+		for _, document := range read {
+			result.Add(document.Name, document.Itinerary, document.Err)
+		}
+	}
+
+	if len(result.Documents) > 1 {
+		summary := result.Summarize()
+		result.Summary = &summary
+	}
+
+	send("result", result.Response())
+
+	log.Info("read stream finished",
+		zap.Int("documents", len(result.Documents)),
+	)
+}
+
+func init() {
+	router.Router.Handle("POST", "/read/stream", StreamHandler)
+}
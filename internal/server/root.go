@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"os"
 	"strconv"
 
@@ -8,12 +9,17 @@ import (
 	"net/http"
 
 	_ "trikliq-airport-finder/internal/config"
+	_ "trikliq-airport-finder/internal/route/airports"
+	_ "trikliq-airport-finder/internal/route/boardingpass"
+	_ "trikliq-airport-finder/internal/route/jobs"
+	_ "trikliq-airport-finder/internal/route/maps"
 	_ "trikliq-airport-finder/internal/route/read"
 	_ "trikliq-airport-finder/pkg/redis"
 
 	"trikliq-airport-finder/internal/server/ca"
 	"trikliq-airport-finder/internal/server/middlewares"
 	"trikliq-airport-finder/internal/server/router"
+	"trikliq-airport-finder/pkg/jobs"
 	"trikliq-airport-finder/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -62,6 +68,11 @@ func Start() {
 		zap.String("keyFile", keyFile),
 	))
 
+	// uploads of /jobs are read by worker processes, single nodes can run workers of their own
+	if jobs.Config.Embedded {
+		go jobs.Run(context.Background(), logger.Log)
+	}
+
 	// Start HTTPS server
 	httpServer = Initialize(ip, port, router.Router)
 	go func() {
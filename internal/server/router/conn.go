@@ -0,0 +1,30 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+type connKey struct{}
+
+// ErrNoConn is returned for requests which were not served by a server with ConnContext
+var ErrNoConn = errors.New("connection of request is unknown")
+
+// ConnContext keeps connection in context of its requests, for ExtendWrite
+func ConnContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connKey{}, conn)
+}
+
+// ExtendWrite moves write deadline of connection of request, responses streamed for longer than WriteTimeout
+// of the server call it before they write
+func ExtendWrite(request *http.Request, timeout time.Duration) error {
+	conn, ok := request.Context().Value(connKey{}).(net.Conn)
+	if !ok {
+		return ErrNoConn
+	}
+
+	return conn.SetWriteDeadline(time.Now().Add(timeout))
+}
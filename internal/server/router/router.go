@@ -2,14 +2,21 @@ package router
 
 import (
 	"trikliq-airport-finder/internal/server/middlewares"
+	"trikliq-airport-finder/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
 var (
-	Router = gin.New()
+	Router = newRouter()
 )
 
+func newRouter() *gin.Engine {
+	// debug output of gin follows logs, subcommands keep stdout for their results
+	gin.DefaultWriter = logger.Output()
+	return gin.New()
+}
+
 func init() {
 	Router.Use(middlewares.NoCache())
 	Router.Use(middlewares.Session())
@@ -13,6 +13,8 @@ import (
 	"syscall"
 	"time"
 
+	routerpkg "trikliq-airport-finder/internal/server/router"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
@@ -71,6 +73,8 @@ func Initialize(ip net.IP, port int, router *gin.Engine) (server *http.Server) {
 		TLSConfig:         tlsConfig,
 		Handler:           router,
 		ErrorLog:          errorLog,
+		// streamed responses move write deadline of their connection
+		ConnContext: routerpkg.ConnContext,
 	}
 	server.SetKeepAlivesEnabled(false)
 
@@ -0,0 +1,104 @@
+package airline
+
+import (
+	"encoding/json"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"trikliq-airport-finder/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// DataPath is location of known carriers, keyed by IATA airline designator
+const DataPath = "data/airlines.json"
+
+// Airline is a carrier with its hub airports
+type Airline struct {
+	Code string   `json:"code"`
+	Name string   `json:"name"`
+	Hubs []string `json:"hubs"`
+}
+
+// FlightNumber is a flight designator found in text, e.g. SQ944
+type FlightNumber struct {
+	Carrier string `json:"carrier"`
+	Number  string `json:"number"`
+}
+
+// String formats flight number as printed on tickets
+func (f FlightNumber) String() string {
+	return f.Carrier + f.Number
+}
+
+//lint:ignore GLOBAL this is okay
+var (
+	airlines     map[string]*Airline
+	airlinesOnce sync.Once
+
+	flightNumberRegex = regexp.MustCompile(`\b([A-Z0-9]{2})\s?(\d{1,4})\b`)
+)
+
+func load() {
+	airlines = make(map[string]*Airline)
+
+	raw, err := os.ReadFile(DataPath)
+	if err != nil {
+		logger.Log.Error("failed to read airlines",
+			zap.String("path", DataPath),
+			zap.Error(err),
+		)
+		return
+	}
+
+	err = json.Unmarshal(raw, &airlines)
+	if err != nil {
+		logger.Log.Error("failed to parse airlines",
+			zap.String("path", DataPath),
+			zap.Error(err),
+		)
+		return
+	}
+
+	for code, a := range airlines {
+		a.Code = code
+	}
+}
+
+// Get returns known airline by its designator
+func Get(code string) (*Airline, bool) {
+	airlinesOnce.Do(load)
+
+	a, found := airlines[strings.ToUpper(strings.TrimSpace(code))]
+	return a, found
+}
+
+// FlightNumbers returns flight numbers of known airlines in order of appearance
+func FlightNumbers(txt string) []FlightNumber {
+	found := make([]FlightNumber, 0)
+
+	for _, match := range flightNumberRegex.FindAllStringSubmatch(txt, -1) {
+		// designator is two characters, but never two digits
+		if strings.Trim(match[1], "0123456789") == "" {
+			continue
+		}
+
+		if _, known := Get(match[1]); !known {
+			continue
+		}
+
+		number := strings.TrimLeft(match[2], "0")
+		if number == "" {
+			number = "0"
+		}
+
+		found = append(found, FlightNumber{
+			Carrier: match[1],
+			Number:  number,
+		})
+	}
+
+	return found
+}
@@ -0,0 +1,40 @@
+package airport
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Airport defines a single record of data/iata.json
+type Airport struct {
+	City      string `json:"city"`
+	Country   string `json:"country"`
+	Elevation string `json:"elevation"`
+	IATA      string `json:"iata"`
+	ICAO      string `json:"icao"`
+	Lat       string `json:"lat"`
+	Lon       string `json:"lon"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	Tz        string `json:"tz"`
+}
+
+// Coordinates returns parsed latitude and longitude, ok is false if airport has no coordinates
+func (a *Airport) Coordinates() (lat, lon float64, ok bool) {
+	if a.Lat == "" || a.Lon == "" {
+		return
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(a.Lat), 64)
+	if err != nil {
+		return
+	}
+
+	lon, err = strconv.ParseFloat(strings.TrimSpace(a.Lon), 64)
+	if err != nil {
+		return
+	}
+
+	ok = true
+	return
+}
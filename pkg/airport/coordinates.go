@@ -0,0 +1,88 @@
+package airport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CoordinatesPath is location of airports.csv of OurAirports, it fills coordinates DataPath does not have
+const CoordinatesPath = "data/airports.csv"
+
+// ErrNoCoordinates is returned by features which need coordinates of airports when none are loaded
+var ErrNoCoordinates = errors.New("airport coordinates are not loaded, add " + CoordinatesPath + " from OurAirports")
+
+// LoadCoordinates fills missing coordinates of airports from airports.csv of OurAirports, rows are matched by
+// IATA code, then by ICAO code. Returns number of airports which got coordinates.
+func (r *Registry) LoadCoordinates(path string) (int, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	reader := csv.NewReader(bytes.NewReader(raw))
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return 0, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range []string{"ident", "gps_code", "iata_code", "latitude_deg", "longitude_deg"} {
+		if _, found := columns[name]; !found {
+			return 0, fmt.Errorf("%s has no %s column", path, name)
+		}
+	}
+
+	filled := 0
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return filled, err
+		}
+
+		a, found := r.IATA(record[columns["iata_code"]])
+		if !found {
+			if a, found = r.ICAO(record[columns["gps_code"]]); !found {
+				a, found = r.ICAO(record[columns["ident"]])
+			}
+		}
+		if !found || a.Lat != "" || a.Lon != "" {
+			continue
+		}
+
+		a.Lat = strings.TrimSpace(record[columns["latitude_deg"]])
+		a.Lon = strings.TrimSpace(record[columns["longitude_deg"]])
+		if _, _, ok := a.Coordinates(); !ok {
+			a.Lat, a.Lon = "", ""
+			continue
+		}
+		filled++
+	}
+
+	if filled > 0 {
+		r.coordinates = true
+		// coordinates are part of responses, so they must change the ETag too
+		r.ETag = fmt.Sprintf("%x", sha256.Sum256(append([]byte(r.ETag), raw...)))
+	}
+
+	return filled, nil
+}
+
+// HasCoordinates reports if airports of registry have coordinates at all, distances, nearby airports
+// and maps need them
+func (r *Registry) HasCoordinates() bool {
+	return r.coordinates
+}
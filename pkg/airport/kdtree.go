@@ -0,0 +1,164 @@
+package airport
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+
+	"trikliq-airport-finder/pkg/geo"
+)
+
+// Nearby is an airport found around a point
+type Nearby struct {
+	Airport    *Airport `json:"airport"`
+	DistanceKm float64  `json:"distanceKm"`
+	DistanceMi float64  `json:"distanceMi"`
+	Bearing    float64  `json:"bearing"`
+}
+
+// kdNode is a node of k-d tree over airports projected on the unit sphere
+type kdNode struct {
+	point       [3]float64
+	id          int
+	axis        int
+	left, right *kdNode
+}
+
+// kdTree is a spatial index, built only from airports which have coordinates
+type kdTree struct {
+	once sync.Once
+	root *kdNode
+}
+
+// buildNearby indexes every airport with coordinates
+func (r *Registry) buildNearby() {
+	nodes := make([]*kdNode, 0, len(r.airports))
+
+	for id, a := range r.airports {
+		lat, lon, ok := a.Coordinates()
+		if !ok || !geo.ValidCoordinates(lat, lon) {
+			continue
+		}
+
+		nodes = append(nodes, &kdNode{
+			point: geo.Cartesian(lat, lon),
+			id:    id,
+		})
+	}
+
+	r.nearby.root = buildKD(nodes, 0)
+}
+
+func buildKD(nodes []*kdNode, depth int) *kdNode {
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	axis := depth % 3
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].point[axis] < nodes[j].point[axis]
+	})
+
+	median := len(nodes) / 2
+	node := nodes[median]
+	node.axis = axis
+	node.left = buildKD(nodes[:median], depth+1)
+	node.right = buildKD(nodes[median+1:], depth+1)
+
+	return node
+}
+
+// candidate is an airport with squared chord distance to the searched point
+type candidate struct {
+	id       int
+	distance float64
+}
+
+// farthestFirst is a max-heap of candidates, so the worst candidate can be dropped
+type farthestFirst []candidate
+
+func (h farthestFirst) Len() int            { return len(h) }
+func (h farthestFirst) Less(i, j int) bool  { return h[i].distance > h[j].distance }
+func (h farthestFirst) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *farthestFirst) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *farthestFirst) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// Nearby returns at most limit airports within radiusKm of a point, closest first
+func (r *Registry) Nearby(lat, lon, radiusKm float64, limit int) []Nearby {
+	r.nearby.once.Do(r.buildNearby)
+
+	result := make([]Nearby, 0)
+	if limit < 1 || !geo.ValidCoordinates(lat, lon) {
+		return result
+	}
+
+	var (
+		target = geo.Cartesian(lat, lon)
+		chord  = geo.Chord(radiusKm)
+		best   = make(farthestFirst, 0, limit)
+	)
+
+	// worst returns squared distance a node has to beat to become a candidate
+	worst := func() float64 {
+		if len(best) < limit {
+			return chord * chord
+		}
+		return best[0].distance
+	}
+
+	var search func(node *kdNode)
+	search = func(node *kdNode) {
+		if node == nil {
+			return
+		}
+
+		distance := 0.0
+		for i := range target {
+			d := node.point[i] - target[i]
+			distance += d * d
+		}
+
+		if distance <= worst() {
+			heap.Push(&best, candidate{id: node.id, distance: distance})
+			if len(best) > limit {
+				heap.Pop(&best)
+			}
+		}
+
+		diff := target[node.axis] - node.point[node.axis]
+		near, far := node.left, node.right
+		if diff > 0 {
+			near, far = node.right, node.left
+		}
+
+		search(near)
+		if diff*diff <= worst() {
+			search(far)
+		}
+	}
+	search(r.nearby.root)
+
+	sort.Slice(best, func(i, j int) bool {
+		return best[i].distance < best[j].distance
+	})
+
+	for _, c := range best {
+		a := r.airports[c.id]
+		aLat, aLon, _ := a.Coordinates()
+		distance := geo.Haversine(lat, lon, aLat, aLon)
+
+		result = append(result, Nearby{
+			Airport:    a,
+			DistanceKm: distance,
+			DistanceMi: distance * geo.KmToMiles,
+			Bearing:    geo.Bearing(lat, lon, aLat, aLon),
+		})
+	}
+
+	return result
+}
@@ -0,0 +1,109 @@
+package airport
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"trikliq-airport-finder/pkg/transform"
+)
+
+// MetroPath is location of metropolitan area codes, keyed by city code
+const MetroPath = "data/metro.json"
+
+// Metro is a metropolitan area served by several airports, e.g. LON for London
+type Metro struct {
+	Code     string   `json:"code"`
+	Name     string   `json:"name"`
+	Country  string   `json:"country"`
+	Airports []string `json:"airports"`
+
+	// Hints are terminal and airport names which point to a single member airport
+	Hints map[string][]string `json:"hints,omitempty"`
+
+	// mention matches folded name of the area as a whole word, it is compiled once when metros are loaded
+	mention *regexp.Regexp
+}
+
+// Mentioned reports if folded text mentions name of the area
+func (m *Metro) Mentioned(folded string) bool {
+	return m.mention != nil && m.mention.MatchString(folded)
+}
+
+// LoadMetros reads metropolitan areas, missing file is ignored
+func (r *Registry) LoadMetros(path string) error {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	metros := make(map[string]*Metro)
+	err = json.Unmarshal(raw, &metros)
+	if err != nil {
+		return err
+	}
+
+	r.metros = make(map[string]*Metro, len(metros))
+	r.metroOf = make(map[string]*Metro)
+
+	for code, metro := range metros {
+		metro.Code = code
+		metro.mention = regexp.MustCompile(`\b` + regexp.QuoteMeta(transform.Fold(metro.Name)) + `\b`)
+		r.metros[code] = metro
+
+		for _, member := range metro.Airports {
+			r.metroOf[member] = metro
+		}
+	}
+
+	return nil
+}
+
+// Metro returns metropolitan area by its city code
+func (r *Registry) Metro(code string) (*Metro, bool) {
+	metro, found := r.metros[strings.ToUpper(strings.TrimSpace(code))]
+	return metro, found
+}
+
+// MetroOf returns metropolitan area airport belongs to
+func (r *Registry) MetroOf(code string) (*Metro, bool) {
+	metro, found := r.metroOf[strings.ToUpper(strings.TrimSpace(code))]
+	return metro, found
+}
+
+// Metros returns every metropolitan area ordered by code
+func (r *Registry) Metros() []*Metro {
+	metros := make([]*Metro, 0, len(r.metros))
+	for _, metro := range r.metros {
+		metros = append(metros, metro)
+	}
+
+	sort.Slice(metros, func(i, j int) bool {
+		return metros[i].Code < metros[j].Code
+	})
+
+	return metros
+}
+
+// ByImportance orders codes of airports from the most important one
+func (r *Registry) ByImportance(codes []string) []string {
+	ordered := append([]string{}, codes...)
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, _ := r.IATA(ordered[i])
+		b, _ := r.IATA(ordered[j])
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return r.Weight(a) > r.Weight(b)
+	})
+
+	return ordered
+}
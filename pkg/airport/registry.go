@@ -0,0 +1,309 @@
+package airport
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"trikliq-airport-finder/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// DataPath is location of the airport dataset, relative to working directory
+const DataPath = "data/iata.json"
+
+// Registry holds in-process airport dataset with lookup indexes
+type Registry struct {
+	airports []*Airport
+	byIATA   map[string]*Airport
+	byICAO   map[string]*Airport
+
+	aliases    map[string][]string
+	importance map[string]float64
+	metros     map[string]*Metro
+	metroOf    map[string]*Metro
+	suggest    suggestIndex
+	nearby     kdTree
+
+	coordinates bool
+
+	// ETag is a digest of the loaded dataset, it changes only if dataset changes
+	ETag string
+}
+
+// Result is a ranked match of a search
+type Result struct {
+	Airport *Airport `json:"airport"`
+	Score   int      `json:"score"`
+}
+
+// Query defines filters of a search, empty fields are ignored
+type Query struct {
+	City    string
+	Country string
+	State   string
+	Text    string
+}
+
+//lint:ignore GLOBAL this is okay
+var (
+	defaultRegistry *Registry
+	defaultOnce     sync.Once
+)
+
+// Default returns registry loaded from DataPath, dataset is read only once per process
+func Default() *Registry {
+	defaultOnce.Do(func() {
+		registry, err := Load(DataPath)
+		if err != nil {
+			logger.Log.Error("failed to load airport registry",
+				zap.String("path", DataPath),
+				zap.Error(err),
+			)
+
+			registry = New(nil)
+		}
+
+		err = registry.LoadRanking(AliasesPath, ImportancePath)
+		if err != nil {
+			logger.Log.Error("failed to load airport ranking",
+				zap.Error(err),
+			)
+		}
+
+		err = registry.LoadMetros(MetroPath)
+		if err != nil {
+			logger.Log.Error("failed to load metropolitan areas",
+				zap.Error(err),
+			)
+		}
+
+		filled, err := registry.LoadCoordinates(CoordinatesPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			logger.Log.Error("failed to load airport coordinates",
+				zap.String("path", CoordinatesPath),
+				zap.Error(err),
+			)
+		}
+
+		if !registry.HasCoordinates() {
+			logger.Log.Warn("airports have no coordinates, nearby airports, distances and maps are unavailable",
+				zap.String("path", CoordinatesPath),
+			)
+		} else if filled > 0 {
+			logger.Log.Info("loaded airport coordinates",
+				zap.String("path", CoordinatesPath),
+				zap.Int("airports", filled),
+			)
+		}
+
+		defaultRegistry = registry
+	})
+
+	return defaultRegistry
+}
+
+// Load reads dataset from path and builds a registry
+func Load(path string) (*Registry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]*Airport)
+	err = json.Unmarshal(raw, &data)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := New(data)
+	registry.ETag = fmt.Sprintf("%x", sha256.Sum256(raw))
+
+	return registry, nil
+}
+
+// New builds registry from records keyed by IATA code
+func New(data map[string]*Airport) *Registry {
+	r := &Registry{
+		airports: make([]*Airport, 0, len(data)),
+		byIATA:   make(map[string]*Airport, len(data)),
+		byICAO:   make(map[string]*Airport, len(data)),
+	}
+
+	for code, record := range data {
+		if record == nil {
+			continue
+		}
+
+		if record.IATA == "" {
+			record.IATA = code
+		}
+
+		r.airports = append(r.airports, record)
+		r.byIATA[strings.ToUpper(code)] = record
+
+		if _, _, ok := record.Coordinates(); ok {
+			r.coordinates = true
+		}
+
+		if record.ICAO != "" {
+			r.byICAO[strings.ToUpper(record.ICAO)] = record
+		}
+	}
+
+	// keep iteration order stable, so search results are deterministic
+	sort.Slice(r.airports, func(i, j int) bool {
+		return r.airports[i].IATA < r.airports[j].IATA
+	})
+
+	return r
+}
+
+// Len returns number of airports in registry
+func (r *Registry) Len() int {
+	return len(r.airports)
+}
+
+// All returns every airport in registry ordered by IATA code
+func (r *Registry) All() []*Airport {
+	return r.airports
+}
+
+// IATA returns airport by IATA code
+func (r *Registry) IATA(code string) (*Airport, bool) {
+	a, found := r.byIATA[strings.ToUpper(strings.TrimSpace(code))]
+	return a, found
+}
+
+// ICAO returns airport by ICAO code
+func (r *Registry) ICAO(code string) (*Airport, bool) {
+	a, found := r.byICAO[strings.ToUpper(strings.TrimSpace(code))]
+	return a, found
+}
+
+// Lookup returns airport by IATA (3 letters) or ICAO (4 letters) code
+func (r *Registry) Lookup(code string) (*Airport, bool) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == 4 {
+		if a, found := r.ICAO(code); found {
+			return a, true
+		}
+	}
+
+	return r.IATA(code)
+}
+
+// Search returns airports matching every filter of query, best matches first
+func (r *Registry) Search(query Query) []Result {
+	var (
+		city    = strings.ToLower(strings.TrimSpace(query.City))
+		country = strings.ToLower(strings.TrimSpace(query.Country))
+		state   = strings.ToLower(strings.TrimSpace(query.State))
+		tokens  = strings.Fields(strings.ToLower(query.Text))
+	)
+
+	results := make([]Result, 0)
+
+	for _, a := range r.airports {
+		score, matched := 0, true
+
+		if city != "" {
+			s := fieldScore(a.City, city)
+			score, matched = score+s, matched && s > 0
+		}
+
+		if matched && country != "" {
+			s := fieldScore(a.Country, country)
+			score, matched = score+s, matched && s == ExactScore
+		}
+
+		if matched && state != "" {
+			s := fieldScore(strings.ReplaceAll(a.State, "-", " "), strings.ReplaceAll(state, "-", " "))
+			score, matched = score+s, matched && s > 0
+		}
+
+		if matched && len(tokens) > 0 {
+			s := textScore(a, tokens)
+			score, matched = score+s, matched && s > 0
+		}
+
+		if !matched {
+			continue
+		}
+
+		results = append(results, Result{
+			Airport: a,
+			Score:   score,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return r.Weight(results[i].Airport) > r.Weight(results[j].Airport)
+	})
+
+	return results
+}
+
+const (
+	// ExactScore is score of a field or word of an airport which matches exactly
+	ExactScore    = 3
+	prefixScore   = 2
+	containsScore = 1
+)
+
+// fieldScore ranks how well value matches field, zero means no match
+func fieldScore(field, value string) int {
+	field = strings.ToLower(field)
+
+	switch {
+	case field == "":
+		return 0
+	case field == value:
+		return ExactScore
+	case strings.HasPrefix(field, value):
+		return prefixScore
+	case strings.Contains(field, value):
+		return containsScore
+	}
+
+	return 0
+}
+
+// textScore ranks full-text match across codes, name and city, every token has to match
+func textScore(a *Airport, tokens []string) (score int) {
+	for _, token := range tokens {
+		best := 0
+
+		if strings.EqualFold(a.IATA, token) || strings.EqualFold(a.ICAO, token) {
+			best = 10
+		}
+
+		if s := fieldScore(a.City, token) * 2; s > best {
+			best = s
+		}
+
+		for _, word := range strings.Fields(a.Name) {
+			if s := fieldScore(word, token); s > best {
+				best = s
+			}
+		}
+
+		if best == 0 {
+			return 0
+		}
+		score += best
+	}
+
+	return
+}
@@ -0,0 +1,181 @@
+package airport
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"trikliq-airport-finder/pkg/pdf"
+	"trikliq-airport-finder/pkg/transform"
+)
+
+const (
+	// AliasesPath is location of alternative airport names, keyed by IATA code
+	AliasesPath = "data/aliases.json"
+	// ImportancePath is location of airport importance weights, keyed by IATA code
+	ImportancePath = "data/importance.json"
+
+	// MaxSuggestions is the most suggestions ranked per prefix
+	MaxSuggestions = 20
+)
+
+// words which are too common in airport names to be useful as a prefix
+var nameStopWords = []string{
+	"airport", "international", "intl", "regional", "domestic", "municipal",
+	"airfield", "aerodrome", "airstrip", "field", "air", "base", "county",
+	"the", "of", "de", "del", "la", "el",
+}
+
+// suggestIndex is a prefix index over codes, cities, names and aliases
+type suggestIndex struct {
+	once    sync.Once
+	trie    *pdf.Trie
+	weights []float64
+}
+
+// LoadRanking reads aliases and importance weights used by Suggest, missing files are ignored
+func (r *Registry) LoadRanking(aliasesPath, importancePath string) error {
+	if raw, err := os.ReadFile(aliasesPath); err == nil {
+		aliases := make(map[string][]string)
+		if err = json.Unmarshal(raw, &aliases); err != nil {
+			return err
+		}
+		r.aliases = aliases
+	}
+
+	if raw, err := os.ReadFile(importancePath); err == nil {
+		importance := make(map[string]float64)
+		if err = json.Unmarshal(raw, &importance); err != nil {
+			return err
+		}
+		r.importance = importance
+	}
+
+	return nil
+}
+
+// Weight ranks airport importance, known hubs come first, followed by international and city airports
+func (r *Registry) Weight(a *Airport) float64 {
+	weight := 1.0
+
+	if strings.Contains(strings.ToLower(a.Name), "international") {
+		weight += 4
+	}
+	if a.City != "" {
+		weight += 2
+	}
+	if a.ICAO != "" && len(a.IATA) == 3 {
+		weight++
+	}
+
+	if importance, found := r.importance[a.IATA]; found {
+		weight += 10 + importance
+	}
+
+	return weight
+}
+
+// Suggest returns airports with a code, city, name or alias starting with text, best first
+func (r *Registry) Suggest(text string, limit int) []*Airport {
+	r.suggest.once.Do(r.buildSuggest)
+
+	if limit < 1 || limit > MaxSuggestions {
+		limit = MaxSuggestions
+	}
+
+	key := compact(transform.Fold(text))
+	if key == "" {
+		return []*Airport{}
+	}
+
+	result := make([]*Airport, 0, limit)
+
+	// exact code match always goes first
+	exact, found := r.Lookup(key)
+	if found {
+		result = append(result, exact)
+	}
+
+	for _, id := range r.suggest.trie.Top(key) {
+		if len(result) == limit {
+			break
+		}
+		if r.airports[id] == exact {
+			continue
+		}
+		result = append(result, r.airports[id])
+	}
+
+	return result
+}
+
+// buildSuggest indexes every airport by its keys and ranks them by weight
+func (r *Registry) buildSuggest() {
+	index := &r.suggest
+	index.trie = pdf.TrieData()
+	index.weights = make([]float64, len(r.airports))
+
+	for id, a := range r.airports {
+		index.weights[id] = r.Weight(a)
+
+		for _, key := range r.suggestKeys(a) {
+			key = compact(key)
+			if key == "" {
+				continue
+			}
+			index.trie.InsertValue(key, id)
+		}
+	}
+
+	index.trie.Rank(MaxSuggestions, func(a, b int) bool {
+		if index.weights[a] != index.weights[b] {
+			return index.weights[a] > index.weights[b]
+		}
+		return a < b
+	})
+}
+
+// suggestKeys lists folded texts under which airport can be found
+func (r *Registry) suggestKeys(a *Airport) []string {
+	keys := []string{
+		transform.Fold(a.IATA),
+		transform.Fold(a.ICAO),
+	}
+
+	phrases := []string{a.City, a.Name}
+	phrases = append(phrases, r.aliases[a.IATA]...)
+
+	for _, phrase := range phrases {
+		phrase = transform.Fold(phrase)
+		keys = append(keys, phrase)
+
+		words := strings.FieldsFunc(phrase, func(ch rune) bool {
+			return ch < 'a' || ch > 'z'
+		})
+		if len(words) < 2 {
+			continue
+		}
+
+		for _, word := range words {
+			if len(word) < 2 || transform.InSlice(word, nameStopWords) {
+				continue
+			}
+			keys = append(keys, word)
+		}
+	}
+
+	return keys
+}
+
+// compact keeps only a-z characters, as only those are indexed by the trie
+func compact(text string) string {
+	var b strings.Builder
+	for _, ch := range text {
+		if ch >= 'a' && ch <= 'z' {
+			b.WriteRune(ch)
+		}
+	}
+
+	return b.String()
+}
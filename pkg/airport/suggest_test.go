@@ -0,0 +1,60 @@
+package airport
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+// prefixes typed into the typeahead, from single letters to whole names
+var prefixes = []string{
+	"s", "si", "sin", "sing", "singapore", "l", "lo", "lon", "london", "lhr", "heathrow", "new", "new y",
+	"ny", "par", "paris", "charles", "fra", "frankfurt", "tok", "tokyo", "hane", "nrt", "sydney", "kingsford",
+	"zur", "zurich", "bali", "den", "dps", "sao", "são paulo", "muenchen", "münchen", "x", "zz",
+}
+
+func registry(b *testing.B) *Registry {
+	b.Helper()
+
+	registry, err := Load(filepath.Join("..", "..", DataPath))
+	if err != nil {
+		b.Fatal(err)
+	}
+	err = registry.LoadRanking(filepath.Join("..", "..", AliasesPath), filepath.Join("..", "..", ImportancePath))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	// the index is built on first use, not per query
+	registry.Suggest("s", MaxSuggestions)
+
+	return registry
+}
+
+// BenchmarkSuggest reports p99 latency of a query, which has to stay under a millisecond
+func BenchmarkSuggest(b *testing.B) {
+	registry := registry(b)
+	durations := make([]time.Duration, 0, b.N)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		registry.Suggest(prefixes[i%len(prefixes)], MaxSuggestions)
+		durations = append(durations, time.Since(start))
+	}
+
+	b.StopTimer()
+
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+	p99 := durations[len(durations)*99/100]
+	b.ReportMetric(float64(p99.Nanoseconds()), "p99-ns")
+
+	if p99 > time.Millisecond {
+		b.Errorf("p99 latency %s is over 1ms", p99)
+	}
+}
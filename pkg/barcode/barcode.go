@@ -0,0 +1,71 @@
+// Package barcode reads 2D barcodes printed on boarding passes
+package barcode
+
+import (
+	"image"
+
+	"trikliq-airport-finder/pkg/barcode/pdf417"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+// formats of barcodes
+const (
+	PDF417     = "pdf417"
+	Aztec      = "aztec"
+	QR         = "qr"
+	DataMatrix = "datamatrix"
+)
+
+// Result is a barcode found in image
+type Result struct {
+	Format string `json:"format"`
+	Text   string `json:"text"`
+}
+
+// readers of formats other than PDF417, in order of how often airlines use them
+func readers() []struct {
+	format string
+	reader gozxing.Reader
+} {
+	return []struct {
+		format string
+		reader gozxing.Reader
+	}{
+		{Aztec, aztec.NewAztecReader()},
+		{QR, qrcode.NewQRCodeReader()},
+		{DataMatrix, datamatrix.NewDataMatrixReader()},
+	}
+}
+
+// Decode returns barcodes found in image, an image usually holds just one
+func Decode(img image.Image) []Result {
+	results := make([]Result, 0)
+
+	if text, err := pdf417.Decode(img); err == nil {
+		results = append(results, Result{Format: PDF417, Text: text})
+	}
+
+	bitmap, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		return results
+	}
+
+	hints := map[gozxing.DecodeHintType]interface{}{
+		gozxing.DecodeHintType_TRY_HARDER: true,
+	}
+
+	for _, r := range readers() {
+		result, err := r.reader.Decode(bitmap, hints)
+		if err != nil {
+			continue
+		}
+
+		results = append(results, Result{Format: r.format, Text: result.GetText()})
+	}
+
+	return results
+}
@@ -0,0 +1,190 @@
+package pdf417
+
+import "errors"
+
+// modulus of the prime field codewords live in, 3 is its generator
+const (
+	modulus   = 929
+	generator = 3
+)
+
+var errUncorrectable = errors.New("pdf417: too many errors")
+
+//lint:ignore GLOBAL this is okay
+var exp, log = func() (exp [modulus]int, log [modulus]int) {
+	x := 1
+	for i := 0; i < modulus-1; i++ {
+		exp[i] = x
+		log[x] = i
+		x = x * generator % modulus
+	}
+	exp[modulus-1] = exp[0]
+
+	return
+}()
+
+func add(a, b int) int { return (a + b) % modulus }
+func sub(a, b int) int { return (a - b + modulus) % modulus }
+func mul(a, b int) int { return a * b % modulus }
+
+func inverse(a int) int {
+	return exp[(modulus-1-log[a])%(modulus-1)]
+}
+
+// power returns generator raised to n
+func power(n int) int {
+	n %= modulus - 1
+	if n < 0 {
+		n += modulus - 1
+	}
+
+	return exp[n]
+}
+
+// evaluate polynomial with coefficients from the lowest degree at x
+func evaluate(poly []int, x int) int {
+	result := 0
+	for i := len(poly) - 1; i >= 0; i-- {
+		result = add(mul(result, x), poly[i])
+	}
+
+	return result
+}
+
+// correct fixes errors and erasures in codewords in place, codewords[0] is the highest degree
+// coefficient of received polynomial and the last ecCount codewords are error correction.
+// Erasures are indexes of codewords which could not be read at all.
+func correct(codewords []int, ecCount int, erasures []int) (corrected int, err error) {
+	n := len(codewords)
+
+	syndromes := make([]int, ecCount)
+	clean := true
+	for k := range syndromes {
+		// received polynomial evaluated at generator^(k+1)
+		x, s := power(k+1), 0
+		for _, c := range codewords {
+			s = add(mul(s, x), c)
+		}
+
+		syndromes[k] = s
+		if s != 0 {
+			clean = false
+		}
+	}
+
+	if clean {
+		return 0, nil
+	}
+
+	if len(erasures) > ecCount {
+		return 0, errUncorrectable
+	}
+
+	// erasure locator, Berlekamp-Massey continues from it
+	locator := []int{1}
+	for _, e := range erasures {
+		x := power(n - 1 - e)
+		locator = multiply(locator, []int{1, sub(0, x)})
+	}
+
+	var (
+		previous = append([]int(nil), locator...)
+		length   = len(erasures)
+		shift    = 1
+		last     = 1
+	)
+
+	for r := len(erasures); r < ecCount; r++ {
+		discrepancy := syndromes[r]
+		for i := 1; i <= length && i < len(locator); i++ {
+			discrepancy = add(discrepancy, mul(locator[i], syndromes[r-i]))
+		}
+
+		if discrepancy == 0 {
+			shift++
+			continue
+		}
+
+		factor := mul(discrepancy, inverse(last))
+		next := append([]int(nil), locator...)
+		for len(next) < len(previous)+shift {
+			next = append(next, 0)
+		}
+		for i, p := range previous {
+			next[i+shift] = sub(next[i+shift], mul(factor, p))
+		}
+
+		if 2*length <= r+len(erasures) {
+			previous = locator
+			length = r + 1 + len(erasures) - length
+			last = discrepancy
+			shift = 1
+		} else {
+			shift++
+		}
+
+		locator = next
+	}
+
+	locator = trim(locator)
+	if len(locator)-1 != length || 2*length-len(erasures) > ecCount {
+		return 0, errUncorrectable
+	}
+
+	// Chien search over every position of received polynomial
+	positions := make([]int, 0, length)
+	for p := 0; p < n; p++ {
+		if evaluate(locator, power(-p)) == 0 {
+			positions = append(positions, p)
+		}
+	}
+
+	if len(positions) != length {
+		return 0, errUncorrectable
+	}
+
+	// Forney algorithm
+	evaluator := multiply(syndromes, locator)
+	if len(evaluator) > ecCount {
+		evaluator = evaluator[:ecCount]
+	}
+
+	derivative := make([]int, len(locator)-1)
+	for i := 1; i < len(locator); i++ {
+		derivative[i-1] = mul(i%modulus, locator[i])
+	}
+
+	for _, p := range positions {
+		x := power(-p)
+
+		denominator := evaluate(derivative, x)
+		if denominator == 0 {
+			return 0, errUncorrectable
+		}
+
+		// error value is -Ω(x)/Λ'(x), subtracting it adds the quotient back
+		index := n - 1 - p
+		codewords[index] = add(codewords[index], mul(evaluate(evaluator, x), inverse(denominator)))
+	}
+
+	return len(positions), nil
+}
+
+func multiply(a, b []int) []int {
+	result := make([]int, len(a)+len(b)-1)
+	for i, x := range a {
+		for j, y := range b {
+			result[i+j] = add(result[i+j], mul(x, y))
+		}
+	}
+
+	return result
+}
+
+func trim(poly []int) []int {
+	for len(poly) > 1 && poly[len(poly)-1] == 0 {
+		poly = poly[:len(poly)-1]
+	}
+
+	return poly
+}
@@ -0,0 +1,409 @@
+// Package pdf417 decodes PDF417 symbols from images, such as boarding pass barcodes
+package pdf417
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sync"
+)
+
+const (
+	// modules of a codeword, start pattern has the same width
+	codewordModules = 17
+
+	maxColumns = 30
+	maxRows    = 90
+)
+
+var (
+	// ErrNotFound is returned when there is no readable PDF417 symbol in image
+	ErrNotFound = errors.New("pdf417: symbol not found")
+
+	startPattern = []float64{8, 1, 1, 1, 1, 1, 1, 3}
+	stopPattern  = []float64{7, 1, 1, 3, 1, 1, 1, 2, 1}
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	lookup     map[int]int
+	lookupOnce sync.Once
+)
+
+// codeword returns value and cluster (0, 1 or 2) of pattern
+func codeword(pattern int) (value, cluster int, found bool) {
+	lookupOnce.Do(func() {
+		lookup = make(map[int]int, 3*len(patterns[0]))
+		for cluster, table := range patterns {
+			for value, pattern := range table {
+				lookup[pattern] = cluster<<10 | value
+			}
+		}
+	})
+
+	packed, found := lookup[pattern]
+	return packed & 0x3ff, packed >> 10, found
+}
+
+// bitmap is an image thresholded to dark and light pixels
+type bitmap struct {
+	width, height int
+	dark          []bool
+}
+
+func (b *bitmap) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= b.width || y >= b.height {
+		return false
+	}
+
+	return b.dark[y*b.width+x]
+}
+
+// rotate returns bitmap turned by 90 degrees clockwise
+func (b *bitmap) rotate() *bitmap {
+	rotated := &bitmap{width: b.height, height: b.width, dark: make([]bool, len(b.dark))}
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			rotated.dark[x*rotated.width+(b.height-1-y)] = b.dark[y*b.width+x]
+		}
+	}
+
+	return rotated
+}
+
+// threshold binarizes image with Otsu's method
+func threshold(img image.Image) *bitmap {
+	bounds := img.Bounds()
+	b := &bitmap{width: bounds.Dx(), height: bounds.Dy()}
+
+	luminance := make([]uint8, b.width*b.height)
+	histogram := [256]int{}
+
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			r, g, bl, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+
+			// transparent pixels are background
+			l := uint8(255)
+			if a > 0 {
+				l = uint8((299*r + 587*g + 114*bl) / 1000 >> 8)
+			}
+
+			luminance[y*b.width+x] = l
+			histogram[l]++
+		}
+	}
+
+	total := len(luminance)
+	sum := 0.0
+	for i, count := range histogram {
+		sum += float64(i * count)
+	}
+
+	var (
+		background, backgroundSum float64
+		best, level               float64
+	)
+	for i, count := range histogram {
+		background += float64(count)
+		if background == 0 {
+			continue
+		}
+		foreground := float64(total) - background
+		if foreground == 0 {
+			break
+		}
+
+		backgroundSum += float64(i * count)
+		mb := backgroundSum / background
+		mf := (sum - backgroundSum) / foreground
+
+		between := background * foreground * (mb - mf) * (mb - mf)
+		if between > best {
+			best = between
+			level = float64(i)
+		}
+	}
+
+	b.dark = make([]bool, total)
+	for i, l := range luminance {
+		b.dark[i] = float64(l) <= level
+	}
+
+	return b
+}
+
+// run is a stretch of pixels of the same color in a row
+type run struct {
+	x, width int
+	dark     bool
+}
+
+func runs(b *bitmap, y int) []run {
+	result := make([]run, 0)
+
+	for x := 0; x < b.width; {
+		start, dark := x, b.at(x, y)
+		for x < b.width && b.at(x, y) == dark {
+			x++
+		}
+		result = append(result, run{x: start, width: x - start, dark: dark})
+	}
+
+	return result
+}
+
+// matches reports if runs starting with a dark one have widths of pattern, returns module width
+func matches(row []run, i int, pattern []float64) (float64, bool) {
+	if i+len(pattern) > len(row) || !row[i].dark {
+		return 0, false
+	}
+
+	total, modules := 0, 0.0
+	for k, p := range pattern {
+		total += row[i+k].width
+		modules += p
+	}
+	module := float64(total) / modules
+
+	for k, p := range pattern {
+		if math.Abs(float64(row[i+k].width)-p*module) > 0.5*module+0.2*p*module {
+			return 0, false
+		}
+	}
+
+	return module, true
+}
+
+// reading is what one row of pixels tells about a row of symbol,
+// values start with left row indicator, -1 stands for unreadable codeword
+type reading struct {
+	row     int
+	cluster int
+	values  []int
+	right   int
+}
+
+// scan reads row of pixels between start and stop pattern
+func scan(b *bitmap, y int) (r reading, found bool) {
+	row := runs(b, y)
+
+	for i := range row {
+		module, ok := matches(row, i, startPattern)
+		if !ok {
+			continue
+		}
+
+		left := float64(row[i].x)
+
+		for j := i + len(startPattern); j < len(row); j++ {
+			stopModule, ok := matches(row, j, stopPattern)
+			if !ok || math.Abs(stopModule-module) > 0.3*module {
+				continue
+			}
+
+			// start, left row indicator, data columns and right row indicator precede the stop pattern
+			span := float64(row[j].x) - left
+			columns := int(math.Round(span/(codewordModules*module))) - 3
+			if columns < 1 || columns > maxColumns {
+				continue
+			}
+			module = span / float64(codewordModules*(columns+3))
+
+			if r, ok = sample(b, y, left, module, columns); ok {
+				return r, true
+			}
+		}
+	}
+
+	return
+}
+
+// sample reads codewords of a row by looking at the middle of every module
+func sample(b *bitmap, y int, left, module float64, columns int) (r reading, found bool) {
+	words := make([]int, columns+2)
+	r.cluster = -1
+
+	for j := range words {
+		words[j] = -1
+
+		start := left + float64(codewordModules*(j+1))*module
+		pattern := 0
+		for k := 0; k < codewordModules; k++ {
+			pattern <<= 1
+			if b.at(int(start+(float64(k)+0.5)*module), y) {
+				pattern |= 1
+			}
+		}
+
+		value, cluster, ok := codeword(pattern)
+		if !ok {
+			continue
+		}
+
+		// every codeword of a row comes from the same cluster
+		if r.cluster == -1 {
+			r.cluster = cluster
+		}
+		if cluster == r.cluster {
+			words[j] = value
+		}
+	}
+
+	leftIndicator, rightIndicator := words[0], words[len(words)-1]
+	indicator := leftIndicator
+	if indicator == -1 {
+		indicator = rightIndicator
+	}
+	if indicator == -1 || r.cluster == -1 {
+		return r, false
+	}
+
+	// left row indicator followed by data columns
+	r.row = 3*(indicator/30) + r.cluster
+	r.values = words[:len(words)-1]
+	r.right = rightIndicator
+
+	return r, r.row < maxRows
+}
+
+// votes counts readings of the same value
+type votes map[int]int
+
+func (v votes) add(value int) {
+	if value >= 0 {
+		v[value]++
+	}
+}
+
+func (v votes) best() int {
+	best, count := -1, 0
+	for value, c := range v {
+		if c > count || (c == count && value < best) {
+			best, count = value, c
+		}
+	}
+
+	return best
+}
+
+// Decode returns text of the first PDF417 symbol found in image, symbol may be rotated by a multiple of 90 degrees
+func Decode(img image.Image) (string, error) {
+	b := threshold(img)
+
+	var err error = ErrNotFound
+	for rotation := 0; rotation < 4; rotation++ {
+		var text string
+		if text, err = decodeBitmap(b); err == nil {
+			return text, nil
+		}
+
+		b = b.rotate()
+	}
+
+	return "", err
+}
+
+func decodeBitmap(b *bitmap) (string, error) {
+	var (
+		cells   = make(map[[2]int]votes)
+		columns = votes{}
+		upper   = votes{}
+		lower   = votes{}
+		level   = votes{}
+		lastRow = -1
+	)
+
+	indicator := func(cluster, value int) {
+		x := value % 30
+		switch cluster {
+		case 0:
+			upper.add(x)
+		case 1:
+			level.add(x / 3)
+			lower.add(x % 3)
+		case 2:
+			columns.add(x + 1)
+		}
+	}
+
+	for y := 0; y < b.height; y++ {
+		r, found := scan(b, y)
+		if !found {
+			continue
+		}
+
+		if r.row > lastRow {
+			lastRow = r.row
+		}
+
+		dataColumns := len(r.values) - 1
+		columns.add(dataColumns)
+
+		if r.values[0] != -1 {
+			indicator(r.cluster, r.values[0])
+		}
+		// right row indicator carries the same values in the next cluster
+		if r.right != -1 {
+			indicator((r.cluster+2)%3, r.right)
+		}
+
+		for c, value := range r.values[1:] {
+			key := [2]int{r.row, c}
+			if cells[key] == nil {
+				cells[key] = votes{}
+			}
+			cells[key].add(value)
+		}
+	}
+
+	if lastRow == -1 {
+		return "", ErrNotFound
+	}
+
+	cols := columns.best()
+	rows := lastRow + 1
+	if upper.best() != -1 && lower.best() != -1 {
+		if indicated := 3*upper.best() + lower.best() + 1; indicated > rows && indicated <= maxRows {
+			rows = indicated
+		}
+	}
+
+	codewords := make([]int, rows*cols)
+	erasures := make([]int, 0)
+	for row := 0; row < rows; row++ {
+		for c := 0; c < cols; c++ {
+			i := row*cols + c
+
+			value := -1
+			if cell := cells[[2]int{row, c}]; cell != nil {
+				value = cell.best()
+			}
+
+			if value == -1 {
+				value = 0
+				erasures = append(erasures, i)
+			}
+			codewords[i] = value
+		}
+	}
+
+	ecCount := 2 << uint(level.best())
+	if level.best() == -1 {
+		ecCount = len(codewords) - codewords[0]
+	}
+	if ecCount < 2 || ecCount >= len(codewords) {
+		return "", ErrNotFound
+	}
+
+	if _, err := correct(codewords, ecCount, erasures); err != nil {
+		return "", err
+	}
+
+	length := codewords[0]
+	if length < 1 || length > len(codewords)-ecCount {
+		return "", errFormat
+	}
+
+	return decodeData(codewords[1:length])
+}
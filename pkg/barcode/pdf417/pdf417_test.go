@@ -0,0 +1,153 @@
+package pdf417
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/boombuler/barcode"
+	encoder "github.com/boombuler/barcode/pdf417"
+)
+
+// boarding pass of IATA resolution 792 with a single leg
+const bcbp = "M1DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J001A0025 100"
+
+// symbol encodes text as PDF417 and draws it with modules of scale pixels on a quiet zone
+func symbol(t *testing.T, text string, level byte, scale int) *image.Gray {
+	t.Helper()
+
+	code, err := encoder.Encode(text, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bounds := code.Bounds()
+	code, err = barcode.Scale(code, bounds.Dx()*scale, bounds.Dy()*scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	margin := 4 * scale
+	img := image.NewGray(image.Rect(0, 0, code.Bounds().Dx()+2*margin, code.Bounds().Dy()+2*margin))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, code.Bounds().Add(image.Pt(margin, margin)), code, code.Bounds().Min, draw.Src)
+
+	return img
+}
+
+// rotate turns image by 90 degrees clockwise
+func rotate(img *image.Gray) *image.Gray {
+	b := img.Bounds()
+	rotated := image.NewGray(image.Rect(0, 0, b.Dy(), b.Dx()))
+
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			rotated.SetGray(b.Dy()-1-y, x, img.GrayAt(x, y))
+		}
+	}
+
+	return rotated
+}
+
+func TestDecodeRoundTrip(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+	}{
+		{"boarding pass", bcbp},
+		{"lower case and punctuation", "Gate closes 20 min before departure, seat 32A; bags: 1pc/23kg!"},
+		{"numeric", "12345678901234567890123456789012345678901234"},
+		{"bytes", "BCBP\x01\x02\x1b\x7f end"},
+		{"two legs", "M2DESMARAIS/LUC       EABC123 YULFRAAC 0834 326J001A0025 100DEF456 FRAGVALH 3664 327C012C0015 100"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			text, err := Decode(symbol(t, c.text, 2, 3))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if text != c.text {
+				t.Errorf("got %q, want %q", text, c.text)
+			}
+		})
+	}
+}
+
+func TestDecodeSecurityLevels(t *testing.T) {
+	for level := byte(0); level <= 5; level++ {
+		text, err := Decode(symbol(t, bcbp, level, 3))
+		if err != nil {
+			t.Fatalf("level %d: %v", level, err)
+		}
+		if text != bcbp {
+			t.Errorf("level %d: got %q", level, text)
+		}
+	}
+}
+
+func TestDecodeRotated(t *testing.T) {
+	img := symbol(t, bcbp, 2, 3)
+
+	for rotation := 90; rotation < 360; rotation += 90 {
+		img = rotate(img)
+
+		text, err := Decode(img)
+		if err != nil {
+			t.Fatalf("%d degrees: %v", rotation, err)
+		}
+		if text != bcbp {
+			t.Errorf("%d degrees: got %q", rotation, text)
+		}
+	}
+}
+
+// stain paints a black rectangle over part of the data columns
+func stain(img *image.Gray) {
+	b := img.Bounds()
+	draw.Draw(img, image.Rect(b.Dx()/2, b.Dy()/3, b.Dx()/2+150, b.Dy()/3+30), image.NewUniform(color.Black), image.Point{}, draw.Src)
+}
+
+func TestDecodeDamaged(t *testing.T) {
+	// error correction codewords of level 4 recover the stain
+	img := symbol(t, bcbp, 4, 3)
+	stain(img)
+
+	text, err := Decode(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != bcbp {
+		t.Errorf("got %q, want %q", text, bcbp)
+	}
+
+	// two codewords of level 0 do not, and a wrong text must not be returned instead
+	img = symbol(t, bcbp, 0, 3)
+	stain(img)
+
+	if text, err := Decode(img); err == nil {
+		t.Errorf("got %q from stained symbol without error correction", text)
+	}
+}
+
+func TestDecodeNotFound(t *testing.T) {
+	blank := image.NewGray(image.Rect(0, 0, 300, 100))
+	draw.Draw(blank, blank.Bounds(), image.White, image.Point{}, draw.Src)
+
+	if _, err := Decode(blank); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want %v", err, ErrNotFound)
+	}
+
+	// bars of a linear barcode are not rows of PDF417
+	bars := image.NewGray(image.Rect(0, 0, 300, 100))
+	draw.Draw(bars, bars.Bounds(), image.White, image.Point{}, draw.Src)
+	for x := 20; x < 280; x += 7 {
+		draw.Draw(bars, image.Rect(x, 10, x+1+x%3, 90), image.Black, image.Point{}, draw.Src)
+	}
+
+	if _, err := Decode(bars); err == nil {
+		t.Error("bars of a linear barcode were decoded")
+	}
+}
@@ -0,0 +1,236 @@
+package pdf417
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// mode latches and shifts
+const (
+	textLatch         = 900
+	byteLatch         = 901
+	numericLatch      = 902
+	byteShift         = 913
+	byteLatchSix      = 924
+	eciCharacterSet   = 927
+	eciGeneralPurpose = 926
+	eciUserDefined    = 925
+	macroOptional     = 923
+	macroTerminator   = 922
+	macroBlock        = 928
+)
+
+// text compaction sub modes
+const (
+	alpha = iota
+	lower
+	mixed
+	punct
+)
+
+const (
+	mixedChars = "0123456789&\r\t,:#-.$/+%*=^"
+	punctChars = ";<>@[\\]_`~!\r\t,:\n-.$/\"|*()?{}'"
+)
+
+var errFormat = errors.New("pdf417: invalid data codewords")
+
+// decodeData turns data codewords, without symbol length descriptor, into text
+func decodeData(codewords []int) (string, error) {
+	var (
+		result strings.Builder
+		i      = 0
+	)
+
+	// symbols start in text compaction mode
+	mode := textLatch
+
+	for i < len(codewords) {
+		switch mode {
+		case textLatch:
+			i = textCompaction(codewords, i, &result)
+		case byteLatch, byteLatchSix:
+			i = byteCompaction(mode, codewords, i, &result)
+		case numericLatch:
+			var err error
+			if i, err = numericCompaction(codewords, i, &result); err != nil {
+				return "", err
+			}
+		}
+
+		if i >= len(codewords) {
+			break
+		}
+
+		code := codewords[i]
+		i++
+
+		switch code {
+		case textLatch, byteLatch, byteLatchSix, numericLatch:
+			mode = code
+		case byteShift:
+			if i < len(codewords) {
+				result.WriteRune(rune(codewords[i]))
+				i++
+			}
+		case eciCharacterSet, eciUserDefined:
+			i++
+		case eciGeneralPurpose:
+			i += 2
+		case macroBlock, macroOptional, macroTerminator:
+			// macro control block only tells how symbols are chained
+			return result.String(), nil
+		default:
+			return "", errFormat
+		}
+	}
+
+	return result.String(), nil
+}
+
+// textCompaction reads two characters from every codeword until a latch, returns index of the latch.
+// Bytes shifted in with 913 do not change text sub mode.
+func textCompaction(codewords []int, i int, result *strings.Builder) int {
+	var (
+		mode    = alpha
+		shifted = -1
+	)
+
+	for ; i < len(codewords); i++ {
+		code := codewords[i]
+
+		if code == byteShift && i+1 < len(codewords) {
+			i++
+			result.WriteRune(rune(byte(codewords[i])))
+			continue
+		}
+
+		if code >= textLatch {
+			break
+		}
+
+		for _, v := range [2]int{code / 30, code % 30} {
+			current := mode
+			if shifted != -1 {
+				current, shifted = shifted, -1
+			}
+
+			mode, shifted = character(current, mode, v, result)
+		}
+	}
+
+	return i
+}
+
+// character writes text value in sub mode current, and returns sub mode and shift for the next value
+func character(current, mode, v int, result *strings.Builder) (int, int) {
+	switch current {
+	case alpha:
+		switch {
+		case v < 26:
+			result.WriteByte(byte('A' + v))
+		case v == 26:
+			result.WriteByte(' ')
+		case v == 27:
+			return lower, -1
+		case v == 28:
+			return mixed, -1
+		case v == 29:
+			return mode, punct
+		}
+
+	case lower:
+		switch {
+		case v < 26:
+			result.WriteByte(byte('a' + v))
+		case v == 26:
+			result.WriteByte(' ')
+		case v == 27:
+			return mode, alpha
+		case v == 28:
+			return mixed, -1
+		case v == 29:
+			return mode, punct
+		}
+
+	case mixed:
+		switch {
+		case v < 25:
+			result.WriteByte(mixedChars[v])
+		case v == 25:
+			return punct, -1
+		case v == 26:
+			result.WriteByte(' ')
+		case v == 27:
+			return lower, -1
+		case v == 28:
+			return alpha, -1
+		case v == 29:
+			return mode, punct
+		}
+
+	case punct:
+		switch {
+		case v < 29:
+			result.WriteByte(punctChars[v])
+		case v == 29:
+			return alpha, -1
+		}
+	}
+
+	return mode, -1
+}
+
+// byteCompaction reads bytes, five codewords carry six bytes
+func byteCompaction(mode int, codewords []int, i int, result *strings.Builder) int {
+	start := i
+	for i < len(codewords) && codewords[i] < textLatch {
+		i++
+	}
+	values := codewords[start:i]
+
+	for len(values) > 0 {
+		// latch 901 leaves the last group one byte per codeword, even when it has five of them
+		if len(values) >= 5 && (mode == byteLatchSix || len(values) > 5) {
+			var group int64
+			for _, v := range values[:5] {
+				group = group*900 + int64(v)
+			}
+
+			for shift := 40; shift >= 0; shift -= 8 {
+				result.WriteRune(rune(byte(group >> uint(shift))))
+			}
+
+			values = values[5:]
+			continue
+		}
+
+		result.WriteRune(rune(byte(values[0])))
+		values = values[1:]
+	}
+
+	return i
+}
+
+// numericCompaction reads digits, every group of up to 15 codewords is one base 900 number
+func numericCompaction(codewords []int, i int, result *strings.Builder) (int, error) {
+	base := big.NewInt(900)
+
+	for i < len(codewords) && codewords[i] < textLatch {
+		group := big.NewInt(0)
+		for n := 0; n < 15 && i < len(codewords) && codewords[i] < textLatch; n++ {
+			group.Mul(group, base)
+			group.Add(group, big.NewInt(int64(codewords[i])))
+			i++
+		}
+
+		digits := group.String()
+		if digits[0] != '1' {
+			return i, errFormat
+		}
+		result.WriteString(digits[1:])
+	}
+
+	return i, nil
+}
@@ -0,0 +1,409 @@
+// Package bcbp decodes IATA Bar Coded Boarding Pass (Resolution 792) strings
+package bcbp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// FormatCode of boarding passes in BCBP format
+	FormatCode = "M"
+
+	// MaxLegs which can be encoded in one boarding pass
+	MaxLegs = 4
+
+	// repeatedLength of mandatory fields repeated for every leg
+	repeatedLength = 37
+)
+
+// BoardingPass is a decoded BCBP string
+type BoardingPass struct {
+	FormatCode           string    `json:"formatCode"`
+	PassengerName        string    `json:"passengerName"`
+	ElectronicTicket     bool      `json:"electronicTicket"`
+	Version              int       `json:"version,omitempty"`
+	PassengerDescription string    `json:"passengerDescription,omitempty"`
+	CheckInSource        string    `json:"checkInSource,omitempty"`
+	IssuanceSource       string    `json:"issuanceSource,omitempty"`
+	IssueDate            string    `json:"issueDate,omitempty"`
+	DocumentType         string    `json:"documentType,omitempty"`
+	Issuer               string    `json:"issuer,omitempty"`
+	BaggageTags          []string  `json:"baggageTags,omitempty"`
+	Legs                 []Leg     `json:"legs"`
+	Security             *Security `json:"security,omitempty"`
+}
+
+// Leg is a flight of boarding pass
+type Leg struct {
+	PNR                  string `json:"pnr"`
+	From                 string `json:"from"`
+	To                   string `json:"to"`
+	Carrier              string `json:"carrier"`
+	FlightNumber         string `json:"flightNumber"`
+	JulianDate           int    `json:"julianDate"`
+	Compartment          string `json:"compartment"`
+	Seat                 string `json:"seat,omitempty"`
+	Sequence             string `json:"sequence,omitempty"`
+	Status               string `json:"status"`
+	AirlineNumericCode   string `json:"airlineNumericCode,omitempty"`
+	DocumentNumber       string `json:"documentNumber,omitempty"`
+	Selectee             string `json:"selectee,omitempty"`
+	DocumentVerification string `json:"documentVerification,omitempty"`
+	MarketingCarrier     string `json:"marketingCarrier,omitempty"`
+	FrequentFlyerAirline string `json:"frequentFlyerAirline,omitempty"`
+	FrequentFlyerNumber  string `json:"frequentFlyerNumber,omitempty"`
+	IDAD                 string `json:"idad,omitempty"`
+	BaggageAllowance     string `json:"baggageAllowance,omitempty"`
+	FastTrack            string `json:"fastTrack,omitempty"`
+	AirlineData          string `json:"airlineData,omitempty"`
+}
+
+// Security is a signature of boarding pass, issuing airline is the only one able to verify it
+type Security struct {
+	Type string `json:"type"`
+	Data string `json:"data"`
+}
+
+// Error tells which field of boarding pass is not valid
+type Error struct {
+	Field  string
+	Offset int
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("bcbp: %s at %d: %s", e.Field, e.Offset, e.Reason)
+}
+
+// reader reads fixed length fields, conditional fields are read from a reader limited by size field
+type reader struct {
+	data   string
+	pos    int
+	offset int
+}
+
+func (r *reader) fail(field, reason string) error {
+	return r.failAt(r.pos, field, reason)
+}
+
+// failAt reports invalid field which starts at pos
+func (r *reader) failAt(pos int, field, reason string) error {
+	return &Error{Field: field, Offset: r.offset + pos, Reason: reason}
+}
+
+func (r *reader) remaining() int {
+	return len(r.data) - r.pos
+}
+
+// take reads mandatory field
+func (r *reader) take(n int, field string) (string, error) {
+	if r.remaining() < n {
+		return "", r.fail(field, "unexpected end of data")
+	}
+
+	value := r.data[r.pos : r.pos+n]
+	r.pos += n
+
+	return value, nil
+}
+
+// optional reads conditional field, conditional fields may be cut at the end of their section
+func (r *reader) optional(n int) string {
+	if r.remaining() < n {
+		n = r.remaining()
+	}
+
+	value := r.data[r.pos : r.pos+n]
+	r.pos += n
+
+	return strings.TrimSpace(value)
+}
+
+// size reads two hexadecimal digits and returns a reader limited to the size they tell
+func (r *reader) size(field string) (*reader, error) {
+	raw, err := r.take(2, field)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := strconv.ParseUint(raw, 16, 8)
+	if err != nil {
+		return nil, r.fail(field, fmt.Sprintf("%q is not a hexadecimal size", raw))
+	}
+
+	if r.remaining() < int(size) {
+		return nil, r.fail(field, fmt.Sprintf("size %d exceeds data", size))
+	}
+
+	section := &reader{data: r.data[r.pos : r.pos+int(size)], offset: r.offset + r.pos}
+	r.pos += int(size)
+
+	return section, nil
+}
+
+// Decode parses and validates BCBP string, nothing but whitespace may follow it
+func Decode(data string) (*BoardingPass, error) {
+	data = strings.TrimRight(data, " \r\n\t")
+
+	pass, consumed, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if consumed != len(data) {
+		return nil, &Error{Field: "boarding pass", Offset: consumed, Reason: "unexpected data after boarding pass"}
+	}
+
+	return pass, nil
+}
+
+// decode parses BCBP at the start of data and returns number of bytes it takes
+func decode(data string) (pass *BoardingPass, consumed int, err error) {
+	r := &reader{data: data}
+	pass = &BoardingPass{}
+
+	if pass.FormatCode, err = r.take(1, "format code"); err != nil {
+		return nil, 0, err
+	}
+	if pass.FormatCode != FormatCode {
+		return nil, 0, r.fail("format code", fmt.Sprintf("%q is not %q", pass.FormatCode, FormatCode))
+	}
+
+	legs, err := r.take(1, "number of legs")
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := strconv.Atoi(legs)
+	if err != nil || count < 1 || count > MaxLegs {
+		return nil, 0, r.fail("number of legs", fmt.Sprintf("%q is not between 1 and %d", legs, MaxLegs))
+	}
+
+	name, err := r.take(20, "passenger name")
+	if err != nil {
+		return nil, 0, err
+	}
+	pass.PassengerName = strings.TrimSpace(name)
+	if pass.PassengerName == "" {
+		return nil, 0, r.fail("passenger name", "empty")
+	}
+
+	indicator, err := r.take(1, "electronic ticket indicator")
+	if err != nil {
+		return nil, 0, err
+	}
+	pass.ElectronicTicket = indicator == "E"
+
+	for i := 0; i < count; i++ {
+		leg, err := decodeLeg(r)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		variable, err := r.size("variable size")
+		if err != nil {
+			return nil, 0, err
+		}
+
+		// unique conditional fields are encoded only with the first leg
+		if i == 0 && variable.remaining() > 0 && variable.data[0] == '>' {
+			if err = decodeUnique(variable, pass); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		// without version there are no conditional fields, later legs hold airline data only
+		if pass.Version > 0 && variable.remaining() >= 2 {
+			if err = decodeRepeated(variable, &leg); err != nil {
+				return nil, 0, err
+			}
+		}
+
+		leg.AirlineData = strings.TrimSpace(variable.data[variable.pos:])
+		pass.Legs = append(pass.Legs, leg)
+	}
+
+	if r.remaining() > 0 && r.data[r.pos] == '^' {
+		r.pos++
+
+		security := &Security{}
+		if security.Type, err = r.take(1, "security type"); err != nil {
+			return nil, 0, err
+		}
+
+		section, err := r.size("security size")
+		if err != nil {
+			return nil, 0, err
+		}
+
+		security.Data = section.data
+		pass.Security = security
+	}
+
+	return pass, r.pos, nil
+}
+
+// decodeLeg reads mandatory fields of a leg
+func decodeLeg(r *reader) (leg Leg, err error) {
+	if r.remaining() < repeatedLength {
+		return leg, r.fail("leg", "unexpected end of data")
+	}
+
+	start := r.pos
+
+	fields := []struct {
+		name   string
+		length int
+		value  *string
+	}{
+		{"pnr", 7, &leg.PNR},
+		{"from", 3, &leg.From},
+		{"to", 3, &leg.To},
+		{"carrier", 3, &leg.Carrier},
+		{"flight number", 5, &leg.FlightNumber},
+	}
+
+	for _, f := range fields {
+		if *f.value, err = r.take(f.length, f.name); err != nil {
+			return
+		}
+		*f.value = strings.TrimSpace(*f.value)
+	}
+
+	for _, code := range []struct {
+		name  string
+		pos   int
+		value string
+	}{{"from", start + 7, leg.From}, {"to", start + 10, leg.To}} {
+		if !letters(code.value) || len(code.value) != 3 {
+			return leg, r.failAt(code.pos, code.name, fmt.Sprintf("%q is not an airport code", code.value))
+		}
+	}
+
+	if len(leg.Carrier) < 2 || !alphanumeric(leg.Carrier) {
+		return leg, r.failAt(start+13, "carrier", fmt.Sprintf("%q is not an airline designator", leg.Carrier))
+	}
+
+	if leg.FlightNumber, err = flightNumber(leg.FlightNumber); err != nil {
+		return leg, r.failAt(start+16, "flight number", err.Error())
+	}
+
+	date, _ := r.take(3, "date of flight")
+	if strings.TrimSpace(date) != "" {
+		leg.JulianDate, err = strconv.Atoi(date)
+		if err != nil || leg.JulianDate < 1 || leg.JulianDate > 366 {
+			return leg, r.failAt(start+21, "date of flight", fmt.Sprintf("%q is not a day of year", date))
+		}
+	}
+
+	leg.Compartment, _ = r.take(1, "compartment")
+	if !letters(leg.Compartment) {
+		return leg, r.failAt(start+24, "compartment", fmt.Sprintf("%q is not a compartment code", leg.Compartment))
+	}
+
+	seat, _ := r.take(4, "seat")
+	leg.Seat = strings.TrimLeft(strings.TrimSpace(seat), "0")
+
+	sequence, _ := r.take(5, "check-in sequence")
+	leg.Sequence = strings.TrimLeft(strings.TrimSpace(sequence), "0")
+
+	leg.Status, _ = r.take(1, "passenger status")
+
+	return leg, nil
+}
+
+// decodeUnique reads version and conditional fields which are encoded once for all legs
+func decodeUnique(r *reader, pass *BoardingPass) error {
+	r.pos++
+
+	version, err := r.take(1, "version")
+	if err != nil {
+		return err
+	}
+	pass.Version, err = strconv.Atoi(version)
+	if err != nil || pass.Version < 1 {
+		return r.fail("version", fmt.Sprintf("%q is not a version number", version))
+	}
+
+	unique, err := r.size("unique size")
+	if err != nil {
+		return err
+	}
+
+	pass.PassengerDescription = unique.optional(1)
+	pass.CheckInSource = unique.optional(1)
+	pass.IssuanceSource = unique.optional(1)
+	pass.IssueDate = unique.optional(4)
+	pass.DocumentType = unique.optional(1)
+	pass.Issuer = unique.optional(3)
+
+	// license plate followed by up to two non consecutive ones since version 5
+	for unique.remaining() > 0 {
+		if tag := unique.optional(13); tag != "" {
+			pass.BaggageTags = append(pass.BaggageTags, tag)
+		}
+	}
+
+	return nil
+}
+
+// decodeRepeated reads conditional fields of a leg
+func decodeRepeated(r *reader, leg *Leg) error {
+	repeated, err := r.size("repeated size")
+	if err != nil {
+		return err
+	}
+
+	leg.AirlineNumericCode = repeated.optional(3)
+	leg.DocumentNumber = repeated.optional(10)
+	leg.Selectee = repeated.optional(1)
+	leg.DocumentVerification = repeated.optional(1)
+	leg.MarketingCarrier = repeated.optional(3)
+	leg.FrequentFlyerAirline = repeated.optional(3)
+	leg.FrequentFlyerNumber = repeated.optional(16)
+	leg.IDAD = repeated.optional(1)
+	leg.BaggageAllowance = repeated.optional(3)
+	leg.FastTrack = repeated.optional(1)
+
+	return nil
+}
+
+// flightNumber validates flight number, e.g. "0834 " or "0012A", and strips its padding
+func flightNumber(value string) (string, error) {
+	digits := strings.TrimRight(value, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	if digits == "" || len(value)-len(digits) > 1 {
+		return "", fmt.Errorf("%q is not a flight number", value)
+	}
+
+	if _, err := strconv.Atoi(digits); err != nil {
+		return "", fmt.Errorf("%q is not a flight number", value)
+	}
+
+	trimmed := strings.TrimLeft(value, "0")
+	if trimmed == "" || trimmed == value[len(digits):] {
+		trimmed = "0" + trimmed
+	}
+
+	return trimmed, nil
+}
+
+func letters(value string) bool {
+	for _, ch := range value {
+		if ch < 'A' || ch > 'Z' {
+			return false
+		}
+	}
+
+	return value != ""
+}
+
+func alphanumeric(value string) bool {
+	for _, ch := range value {
+		if (ch < 'A' || ch > 'Z') && (ch < '0' || ch > '9') {
+			return false
+		}
+	}
+
+	return value != ""
+}
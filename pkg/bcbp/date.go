@@ -0,0 +1,106 @@
+package bcbp
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// julian returns the day of year in year, false if the year is too short
+func julian(year, day int) (time.Time, bool) {
+	date := time.Date(year, time.January, day, 0, 0, 0, 0, time.UTC)
+	return date, date.Year() == year
+}
+
+// Issued resolves date of issue to the latest matching date not after reference,
+// date of issue only carries the last digit of its year
+func (p *BoardingPass) Issued(reference time.Time) (time.Time, bool) {
+	if len(p.IssueDate) != 4 {
+		return time.Time{}, false
+	}
+
+	digit, err := strconv.Atoi(p.IssueDate[:1])
+	if err != nil {
+		return time.Time{}, false
+	}
+	day, err := strconv.Atoi(p.IssueDate[1:])
+	if err != nil || day < 1 || day > 366 {
+		return time.Time{}, false
+	}
+
+	year := reference.Year() - (reference.Year()%10-digit+10)%10
+	for ; year > reference.Year()-20; year -= 10 {
+		if date, ok := julian(year, day); ok && !date.After(reference) {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Date resolves julian date of leg, which has no year. The first matching date on or after
+// date of issue is used, without date of issue the matching date closest to reference is.
+func (p *BoardingPass) Date(leg int, reference time.Time) (time.Time, bool) {
+	if leg < 0 || leg >= len(p.Legs) || p.Legs[leg].JulianDate == 0 {
+		return time.Time{}, false
+	}
+	day := p.Legs[leg].JulianDate
+
+	if issued, ok := p.Issued(reference); ok {
+		for year := issued.Year(); year <= issued.Year()+1; year++ {
+			if date, ok := julian(year, day); ok && !date.Before(issued) {
+				return date, true
+			}
+		}
+	}
+
+	var (
+		closest time.Time
+		found   bool
+	)
+	for year := reference.Year() - 1; year <= reference.Year()+1; year++ {
+		date, ok := julian(year, day)
+		if !ok {
+			continue
+		}
+
+		if !found || distance(date, reference) < distance(closest, reference) {
+			closest, found = date, true
+		}
+	}
+
+	return closest, found
+}
+
+func distance(a, b time.Time) time.Duration {
+	if a.After(b) {
+		return a.Sub(b)
+	}
+	return b.Sub(a)
+}
+
+//lint:ignore GLOBAL this is okay
+var startRegex = regexp.MustCompile(`M[1-4]`)
+
+// Find returns every valid boarding pass in text, e.g. text extracted from PDF or email
+func Find(txt string) []*BoardingPass {
+	passes := make([]*BoardingPass, 0)
+
+	for offset := 0; offset < len(txt); {
+		start := startRegex.FindStringIndex(txt[offset:])
+		if start == nil {
+			break
+		}
+
+		pass, consumed, err := decode(txt[offset+start[0]:])
+		if err != nil {
+			offset += start[0] + 1
+			continue
+		}
+
+		passes = append(passes, pass)
+		offset += start[0] + consumed
+	}
+
+	return passes
+}
@@ -0,0 +1,159 @@
+package classify
+
+import (
+	"math"
+	"strings"
+
+	"trikliq-airport-finder/pkg/airline"
+	"trikliq-airport-finder/pkg/transform"
+)
+
+const (
+	ETicket        = "e-ticket"
+	BoardingPass   = "boarding-pass"
+	ScheduleChange = "schedule-change"
+	Cancellation   = "cancellation"
+	Receipt        = "receipt"
+	Other          = "other"
+)
+
+// Document is a result of classification
+type Document struct {
+	Type       string             `json:"type"`
+	Confidence float64            `json:"confidence"`
+	Travel     bool               `json:"travel"`
+	Scores     map[string]float64 `json:"-"`
+}
+
+// keyword is a phrase pointing to a document type, weight tells how strongly
+type keyword struct {
+	phrase string
+	weight float64
+}
+
+// wholeWords are keywords which are also parts of common words, e.g. "seq" of "subsequent" and "sequence" of
+// "consequence", they match as whole words only
+var wholeWords = map[string]bool{"seq": true, "sequence": true, "pnr": true}
+
+// in reports if folded text contains keyword
+func (k keyword) in(folded string) bool {
+	if !wholeWords[k.phrase] {
+		return strings.Contains(folded, k.phrase)
+	}
+
+	for offset := 0; ; {
+		i := strings.Index(folded[offset:], k.phrase)
+		if i == -1 {
+			return false
+		}
+
+		start, end := offset+i, offset+i+len(k.phrase)
+		if !wordByte(folded, start-1) && !wordByte(folded, end) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+// wordByte reports if byte at i of text is part of a word, positions outside of text are not
+func wordByte(txt string, i int) bool {
+	if i < 0 || i >= len(txt) {
+		return false
+	}
+
+	c := txt[i]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+var keywords = map[string][]keyword{
+	ETicket: {
+		{"e-ticket", 3}, {"eticket", 3}, {"electronic ticket", 3}, {"ticket number", 2},
+		{"itinerary", 1.5}, {"booking reference", 1}, {"booking confirmation", 2},
+		{"your flights", 1}, {"travel itinerary", 2},
+	},
+	BoardingPass: {
+		{"boarding pass", 4}, {"boarding time", 3}, {"boarding group", 2}, {"zone", 0.5},
+		{"gate closes", 2}, {"seq", 1}, {"sequence", 1},
+	},
+	ScheduleChange: {
+		{"schedule change", 4}, {"flight change", 3}, {"has been changed", 3}, {"retimed", 3},
+		{"new departure time", 3}, {"new time", 2}, {"revised", 1.5}, {"previous flight", 2},
+	},
+	Cancellation: {
+		{"cancelled", 3}, {"canceled", 3}, {"cancellation", 3}, {"has been cancelled", 2},
+		{"refund", 1.5}, {"no longer operate", 2},
+	},
+	Receipt: {
+		{"receipt", 3}, {"tax invoice", 3}, {"invoice", 2}, {"total paid", 2}, {"amount paid", 2},
+		{"payment", 1}, {"grand total", 1.5}, {"taxes", 1}, {"fare", 0.5},
+	},
+}
+
+// travelKeywords are phrases common to every travel document
+var travelKeywords = []keyword{
+	{"flight", 1}, {"departure", 1}, {"departing", 1}, {"arrival", 1}, {"arriving", 1},
+	{"airport", 1}, {"passenger", 1}, {"airline", 1}, {"terminal", 0.5}, {"baggage", 0.5},
+	{"booking reference", 1}, {"pnr", 1}, {"check-in", 0.5},
+}
+
+// minimum travel score of a travel document
+const travelThreshold = 3
+
+// Classify labels document text with its type and confidence, documents
+// without flight numbers and travel vocabulary are labeled as other
+func Classify(txt string) (document Document) {
+	folded := transform.Fold(txt)
+
+	document.Scores = make(map[string]float64, len(keywords))
+	total := 0.0
+
+	for docType, phrases := range keywords {
+		score := 0.0
+		for _, k := range phrases {
+			if k.in(folded) {
+				score += k.weight
+			}
+		}
+
+		document.Scores[docType] = score
+		total += score
+	}
+
+	travel := float64(len(airline.FlightNumbers(txt)))
+	for _, k := range travelKeywords {
+		if k.in(folded) {
+			travel += k.weight
+		}
+	}
+
+	document.Type = Other
+	document.Travel = travel >= travelThreshold
+	if !document.Travel {
+		document.Confidence = round(1 - travel/travelThreshold)
+		return
+	}
+
+	best := 0.0
+	for _, docType := range []string{BoardingPass, ScheduleChange, Cancellation, ETicket, Receipt} {
+		if document.Scores[docType] > best {
+			best = document.Scores[docType]
+			document.Type = docType
+		}
+	}
+
+	// travel document without any specific vocabulary is most likely an itinerary
+	if best == 0 {
+		document.Type = ETicket
+		document.Confidence = 0.3
+		return
+	}
+
+	// share of the winning type, damped when only a few phrases were found
+	document.Confidence = round(best / total * math.Min(1, best/4))
+
+	return
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
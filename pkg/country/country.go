@@ -0,0 +1,110 @@
+package country
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+
+	"trikliq-airport-finder/pkg/logger"
+	"trikliq-airport-finder/pkg/transform"
+
+	"go.uber.org/zap"
+)
+
+// DataPath is location of country dataset, keyed by ISO 3166 alpha-2 code
+const DataPath = "data/countries.json"
+
+const (
+	GroupEU       = "EU"
+	GroupEEA      = "EEA"
+	GroupSchengen = "Schengen"
+)
+
+const (
+	Domestic      = "domestic"
+	IntraSchengen = "intra-schengen"
+	International = "international"
+)
+
+// Country is a single record of country dataset
+type Country struct {
+	Code      string   `json:"code"`
+	Name      string   `json:"name"`
+	Continent string   `json:"continent"`
+	Region    string   `json:"region"`
+	Subregion string   `json:"subregion"`
+	Groups    []string `json:"groups"`
+}
+
+// In reports if country is a member of group, e.g. Schengen
+func (c *Country) In(group string) bool {
+	return transform.InSlice(group, c.Groups)
+}
+
+//lint:ignore GLOBAL this is okay
+var (
+	countries     map[string]*Country
+	countriesOnce sync.Once
+)
+
+func load() {
+	countries = make(map[string]*Country)
+
+	raw, err := os.ReadFile(DataPath)
+	if err != nil {
+		logger.Log.Error("failed to read countries",
+			zap.String("path", DataPath),
+			zap.Error(err),
+		)
+		return
+	}
+
+	err = json.Unmarshal(raw, &countries)
+	if err != nil {
+		logger.Log.Error("failed to parse countries",
+			zap.String("path", DataPath),
+			zap.Error(err),
+		)
+		return
+	}
+
+	for code, c := range countries {
+		c.Code = code
+	}
+}
+
+// Get returns country by its two-letter code
+func Get(code string) (*Country, bool) {
+	countriesOnce.Do(load)
+
+	c, found := countries[strings.ToUpper(strings.TrimSpace(code))]
+	return c, found
+}
+
+// Classify tells if flight between two countries is domestic, within Schengen area or international
+func Classify(from, to string) string {
+	if from == "" || to == "" {
+		return ""
+	}
+
+	if strings.EqualFold(from, to) {
+		return Domestic
+	}
+
+	departure, found := Get(from)
+	if !found {
+		return International
+	}
+
+	arrival, found := Get(to)
+	if !found {
+		return International
+	}
+
+	if departure.In(GroupSchengen) && arrival.In(GroupSchengen) {
+		return IntraSchengen
+	}
+
+	return International
+}
@@ -1,7 +1,9 @@
 package crypto
 
 import (
+	"crypto/hmac"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 )
 
@@ -23,3 +25,11 @@ func HashPassword(password string) string {
 	password = fmt.Sprintf("%s.%s", salt, SHA256(password))
 	return password
 }
+
+// HMAC returns hex of HMAC-SHA256 of message with key
+func HMAC(key, message []byte) string {
+	h := hmac.New(sha256.New, key)
+	h.Write(message)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
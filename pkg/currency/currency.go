@@ -0,0 +1,72 @@
+// Package currency reads currency dataset
+package currency
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"trikliq-airport-finder/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// DataPath is location of currency dataset, keyed by ISO 4217 code
+const DataPath = "data/moneycode.json"
+
+// Currency is a single record of currency dataset
+type Currency struct {
+	Code          string `json:"code"`
+	Name          string `json:"name"`
+	Symbol        string `json:"symbol"`
+	SymbolNative  string `json:"symbol_native"`
+	DecimalDigits int    `json:"decimal_digits"`
+}
+
+// Round rounds amount to minor units of currency, e.g. cents
+func (c *Currency) Round(amount float64) float64 {
+	scale := math.Pow(10, float64(c.DecimalDigits))
+	return math.Round(amount*scale) / scale
+}
+
+//lint:ignore GLOBAL this is okay
+var (
+	currencies     map[string]*Currency
+	currenciesOnce sync.Once
+)
+
+func load() {
+	currencies = make(map[string]*Currency)
+
+	raw, err := os.ReadFile(DataPath)
+	if err != nil {
+		logger.Log.Error("failed to read currencies",
+			zap.String("path", DataPath),
+			zap.Error(err),
+		)
+		return
+	}
+
+	err = json.Unmarshal(raw, &currencies)
+	if err != nil {
+		logger.Log.Error("failed to parse currencies",
+			zap.String("path", DataPath),
+			zap.Error(err),
+		)
+		return
+	}
+
+	for code, c := range currencies {
+		c.Code = code
+	}
+}
+
+// Get returns currency by its three-letter code
+func Get(code string) (*Currency, bool) {
+	currenciesOnce.Do(load)
+
+	c, found := currencies[strings.ToUpper(strings.TrimSpace(code))]
+	return c, found
+}
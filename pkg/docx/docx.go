@@ -0,0 +1,164 @@
+// Package docx extracts text of Word documents
+package docx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+)
+
+const (
+	DocumentFile = "word/document.xml"
+
+	// maxDocumentSize of document.xml, text of itineraries is a few hundred kilobytes at most
+	maxDocumentSize = 50 << 20
+)
+
+var (
+	// ErrNotDocument is returned for archives without word/document.xml
+	ErrNotDocument = errors.New("docx: word/document.xml not found")
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	spacesRegex     = regexp.MustCompile(`[ \f\r\v\x{00a0}]+`)
+	emptyLinesRegex = regexp.MustCompile(`\n{3,}`)
+)
+
+// IsDocument reports if raw is a zip archive with Word document
+func IsDocument(raw []byte) bool {
+	archive, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return false
+	}
+
+	for _, f := range archive.File {
+		if f.Name == DocumentFile {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Text returns text of document body. Paragraphs are put on their own lines, table rows too with cells
+// separated by tabs, so values stay next to their labels as they do in PDFs.
+func Text(raw []byte) (string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return "", err
+	}
+
+	for _, f := range archive.File {
+		if f.Name != DocumentFile {
+			continue
+		}
+
+		r, err := f.Open()
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+
+		txt, err := render(io.LimitReader(r, maxDocumentSize))
+		if err != nil {
+			return "", err
+		}
+
+		return txt, nil
+	}
+
+	return "", ErrNotDocument
+}
+
+// render walks WordprocessingML, only text of runs is kept. Deleted text and field codes are dropped
+// as they are elements of their own.
+func render(r io.Reader) (string, error) {
+	var (
+		b       strings.Builder
+		decoder = xml.NewDecoder(r)
+		inText  = false
+		inRun   = false // tab elements outside runs are tab stops, not tabs
+		cells   = 0     // depth of table cells, paragraphs of a cell stay on the row's line
+	)
+
+	// separator within a cell is a space, so cells stay aligned with the row
+	separator := func(outside string) string {
+		if cells > 0 {
+			return " "
+		}
+		return outside
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "r":
+				inRun = true
+			case "t":
+				inText = inRun
+			case "tab":
+				if inRun {
+					b.WriteString(separator("\t"))
+				}
+			case "br", "cr":
+				if inRun {
+					b.WriteString(separator("\n"))
+				}
+			case "noBreakHyphen":
+				b.WriteString("-")
+			case "tc":
+				cells++
+				b.WriteString("\t")
+			}
+
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "r":
+				inRun = false
+			case "t":
+				inText = false
+			case "p":
+				b.WriteString(separator("\n"))
+			case "tc":
+				cells--
+			case "tr":
+				b.WriteString("\n")
+			}
+
+		case xml.CharData:
+			if inText {
+				b.Write(t)
+			}
+		}
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		cells := strings.Split(line, "\t")
+		kept := cells[:0]
+		for _, cell := range cells {
+			cell = strings.TrimSpace(spacesRegex.ReplaceAllString(cell, " "))
+			if cell != "" {
+				kept = append(kept, cell)
+			}
+		}
+		lines[i] = strings.Join(kept, "\t")
+	}
+
+	txt := emptyLinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(txt), nil
+}
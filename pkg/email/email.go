@@ -0,0 +1,375 @@
+// Package email reads MIME messages and mbox mailboxes
+package email
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const (
+	// maxPartSize of a single decoded part, attachments bigger than that are not tickets
+	maxPartSize = 25 << 20
+
+	// maxDepth of nested multiparts and forwarded messages
+	maxDepth = 10
+)
+
+var (
+	// ErrEmpty is returned for mailboxes without messages
+	ErrEmpty = errors.New("email: no messages found")
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	// From sender@example.com Sun Mar 12 09:30:00 2023
+	separatorRegex = regexp.MustCompile(`(?m)^From \S*.*$`)
+	// >From, >>From escaped by mboxrd
+	escapedFromRegex = regexp.MustCompile(`(?m)^>(>*From )`)
+	// Header-Name: value
+	headerRegex = regexp.MustCompile(`^[!-9;-~]+:`)
+
+	decoder = &mime.WordDecoder{CharsetReader: charsetReader}
+)
+
+// headers of which at least two have to be present for text to be recognized as message
+var knownHeaders = []string{
+	"From", "To", "Subject", "Date", "Message-Id", "Mime-Version", "Received", "Return-Path", "Content-Type",
+}
+
+// Message is an email with its bodies and attachments, parts of forwarded messages are included
+type Message struct {
+	From        string    `json:"from,omitempty"`
+	To          string    `json:"to,omitempty"`
+	Subject     string    `json:"subject,omitempty"`
+	Date        time.Time `json:"date,omitempty"`
+	Bodies      []Part    `json:"bodies"`
+	Attachments []Part    `json:"attachments"`
+}
+
+// Part is a decoded leaf of MIME tree, text parts are converted to UTF-8
+type Part struct {
+	// ID is position of part in MIME tree, e.g. 1.2
+	ID          string `json:"id"`
+	ContentType string `json:"contentType"`
+	Filename    string `json:"filename,omitempty"`
+	Content     []byte `json:"-"`
+}
+
+// Name returns filename of part, or its position if it has none
+func (p Part) Name() string {
+	if p.Filename != "" {
+		return p.Filename
+	}
+
+	return "part " + p.ID
+}
+
+// IsMbox reports if raw starts with mbox separator line
+func IsMbox(raw []byte) bool {
+	return bytes.HasPrefix(raw, []byte("From ")) && !IsMessage(raw)
+}
+
+// IsMessage reports if raw starts with a block of headers usual in emails
+func IsMessage(raw []byte) bool {
+	if len(raw) > 16<<10 {
+		raw = raw[:16<<10]
+	}
+
+	found := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+
+		// folded header continues on lines starting with whitespace
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		if !headerRegex.MatchString(line) {
+			return false
+		}
+
+		name := line[:strings.IndexByte(line, ':')]
+		found[strings.ToLower(name)] = true
+	}
+
+	count := 0
+	for _, h := range knownHeaders {
+		if found[strings.ToLower(h)] {
+			count++
+		}
+	}
+
+	return count >= 2
+}
+
+// Read reads single message, or every message of mbox. Messages of mailbox which can not be parsed are skipped,
+// error is only returned if there are none left.
+func Read(raw []byte) ([]*Message, error) {
+	if !IsMbox(raw) {
+		message, err := Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		return []*Message{message}, nil
+	}
+
+	var (
+		messages = make([]*Message, 0)
+		last     error
+	)
+
+	for _, entry := range split(raw) {
+		message, err := Parse(entry)
+		if err != nil {
+			last = err
+			continue
+		}
+		messages = append(messages, message)
+	}
+
+	if len(messages) == 0 {
+		if last != nil {
+			return nil, last
+		}
+		return nil, ErrEmpty
+	}
+
+	return messages, nil
+}
+
+// split cuts mbox into messages and removes escaping of lines starting with From
+func split(raw []byte) [][]byte {
+	raw = bytes.ReplaceAll(raw, []byte("\r\n"), []byte("\n"))
+
+	separators := separatorRegex.FindAllIndex(raw, -1)
+	entries := make([][]byte, 0, len(separators))
+
+	for i, s := range separators {
+		// separator has to follow an empty line, otherwise it is a line of body
+		if s[0] > 0 && !bytes.HasSuffix(raw[:s[0]], []byte("\n\n")) {
+			continue
+		}
+
+		end := len(raw)
+		for _, next := range separators[i+1:] {
+			if bytes.HasSuffix(raw[:next[0]], []byte("\n\n")) {
+				end = next[0]
+				break
+			}
+		}
+
+		entry := bytes.TrimLeft(raw[s[1]:end], "\n")
+		entry = escapedFromRegex.ReplaceAll(entry, []byte("$1"))
+		if len(bytes.TrimSpace(entry)) > 0 {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Parse reads a single message and walks its MIME tree
+func Parse(raw []byte) (*Message, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("email: %w", err)
+	}
+
+	message := &Message{
+		From:    header(msg.Header, "From"),
+		To:      header(msg.Header, "To"),
+		Subject: header(msg.Header, "Subject"),
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		message.Date = date.UTC()
+	}
+
+	if err := message.walk(msg.Header, msg.Body, "1", 0); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// walk adds leaves of MIME entity with given headers and body to message
+func (m *Message) walk(headers map[string][]string, body io.Reader, id string, depth int) error {
+	if depth > maxDepth {
+		return fmt.Errorf("email: part %s is nested too deep", id)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(first(headers, "Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		reader := multipart.NewReader(body, params["boundary"])
+		for i := 1; ; i++ {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("email: part %s: %w", id, err)
+			}
+
+			if err := m.walk(part.Header, part, id+"."+strconv.Itoa(i), depth+1); err != nil {
+				return err
+			}
+		}
+
+	case mediaType == "message/rfc822":
+		// forwarded confirmations keep the original message as a part
+		forwarded, err := mail.ReadMessage(transferDecoded(headers, body))
+		if err != nil {
+			return fmt.Errorf("email: part %s: %w", id, err)
+		}
+		return m.walk(forwarded.Header, forwarded.Body, id+".1", depth+1)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(transferDecoded(headers, body), maxPartSize))
+	if err != nil {
+		return fmt.Errorf("email: part %s: %w", id, err)
+	}
+
+	disposition, dispositionParams, _ := mime.ParseMediaType(first(headers, "Content-Disposition"))
+	filename := dispositionParams["filename"]
+	if filename == "" {
+		filename = params["name"]
+	}
+	if decoded, err := decoder.DecodeHeader(filename); err == nil {
+		filename = decoded
+	}
+
+	part := Part{
+		ID:          id,
+		ContentType: mediaType,
+		Filename:    filename,
+		Content:     content,
+	}
+
+	inline := disposition != "attachment" && filename == ""
+	if inline && (mediaType == "text/plain" || mediaType == "text/html") {
+		part.Content = toUTF8(content, params["charset"])
+		m.Bodies = append(m.Bodies, part)
+		return nil
+	}
+
+	m.Attachments = append(m.Attachments, part)
+	return nil
+}
+
+// transferDecoded undoes content transfer encoding, multipart reader already removes quoted-printable
+func transferDecoded(headers map[string][]string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(first(headers, "Content-Transfer-Encoding"))) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{reader: body})
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+
+	return body
+}
+
+// base64Cleaner drops whitespace and anything after padding, which mailers leave between encoded lines
+type base64Cleaner struct {
+	reader io.Reader
+	done   bool
+}
+
+func (c *base64Cleaner) Read(p []byte) (int, error) {
+	for {
+		if c.done {
+			return 0, io.EOF
+		}
+
+		n, err := c.reader.Read(p)
+		kept := 0
+		for _, b := range p[:n] {
+			switch {
+			case b == '=':
+				p[kept] = b
+				kept++
+			case b >= 'A' && b <= 'Z', b >= 'a' && b <= 'z', b >= '0' && b <= '9', b == '+', b == '/':
+				// data after padding belongs to nothing
+				if kept > 0 && p[kept-1] == '=' {
+					c.done = true
+					return kept, nil
+				}
+				p[kept] = b
+				kept++
+			}
+		}
+
+		if kept > 0 || err != nil {
+			return kept, err
+		}
+	}
+}
+
+// toUTF8 converts text in charset to UTF-8, unknown charsets are left as they are
+func toUTF8(content []byte, charset string) []byte {
+	charset = strings.ToLower(strings.TrimSpace(charset))
+	if charset == "" || charset == "utf-8" || charset == "us-ascii" {
+		return content
+	}
+
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return content
+	}
+
+	converted, err := encoding.NewDecoder().Bytes(content)
+	if err != nil {
+		return content
+	}
+
+	return converted
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	encoding, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+
+	return encoding.NewDecoder().Reader(input), nil
+}
+
+// header returns decoded value of header
+func header(headers mail.Header, key string) string {
+	value := headers.Get(key)
+	if decoded, err := decoder.DecodeHeader(value); err == nil {
+		return decoded
+	}
+
+	return value
+}
+
+// first returns the first value of header, headers of parts are not mail.Header
+func first(headers map[string][]string, key string) string {
+	if values := headers[key]; len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
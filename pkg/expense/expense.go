@@ -0,0 +1,347 @@
+// Package expense turns receipts of itineraries into rows of expense reports, columns of rows are mapped
+// by profiles of expense tools or by mappings of users
+package expense
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airline"
+	"trikliq-airport-finder/pkg/currency"
+)
+
+// category of every row, all of them are flights
+const category = "Airfare"
+
+// Row is an expense, a receipt of one document
+type Row struct {
+	Document      string
+	Passengers    []string
+	TicketNumbers []string
+	Reservation   string
+	Route         string
+	DepartureDate string
+	ReturnDate    string
+	Airline       string
+	IssueDate     string
+	Currency      string
+	Fare          float64
+	Taxes         float64
+	Total         float64
+	Miles         int
+}
+
+// NewRow returns expense of itinerary, found is false if itinerary has no receipt
+func NewRow(name string, itinerary model.Itinerary) (row Row, found bool) {
+	receipt := itinerary.Receipt
+	if receipt == nil {
+		return Row{}, false
+	}
+
+	row = Row{
+		Document:      name,
+		Passengers:    make([]string, 0, len(itinerary.Passengers)),
+		TicketNumbers: receipt.TicketNumbers,
+		Reservation:   receipt.Reservation,
+		Route:         route(itinerary.Segments),
+		IssueDate:     receipt.IssueDate,
+		Currency:      receipt.Currency,
+		Fare:          receipt.Fare,
+		Taxes:         receipt.Taxes,
+		Total:         receipt.Total,
+		Miles:         receipt.Miles,
+	}
+
+	for _, p := range itinerary.Passengers {
+		row.Passengers = append(row.Passengers, p.Name)
+	}
+
+	if len(itinerary.Segments) > 0 {
+		first, last := itinerary.Segments[0], itinerary.Segments[len(itinerary.Segments)-1]
+		row.DepartureDate = first.Date
+		if len(itinerary.Segments) > 1 {
+			row.ReturnDate = last.Date
+		}
+		if row.Reservation == "" {
+			row.Reservation = first.Reservation
+		}
+
+		// airline of the first flight is the merchant, it issued the ticket
+		if len(first.FlightNumber) > 2 {
+			row.Airline = first.FlightNumber[:2]
+			if a, found := airline.Get(row.Airline); found {
+				row.Airline = a.Name
+			}
+		}
+	}
+
+	return row, true
+}
+
+// route joins airports of segments, e.g. SIN-DPS-SIN, segments not connected to previous ones start with /
+func route(segments []model.Segment) string {
+	var b strings.Builder
+
+	for i, s := range segments {
+		if i == 0 || segments[i-1].Arrival != s.Departure {
+			if i > 0 {
+				b.WriteString("/")
+			}
+			b.WriteString(s.Departure)
+		}
+		b.WriteString("-" + s.Arrival)
+	}
+
+	return b.String()
+}
+
+// Field is a named value of row which columns refer to
+type Field struct {
+	Name        string
+	Description string
+
+	value func(row Row) string
+}
+
+// Fields of rows in order they are listed
+var Fields = []Field{
+	{Name: "document", Description: "name of document", value: func(r Row) string { return r.Document }},
+	{Name: "passenger", Description: "passengers, separated by semicolons", value: func(r Row) string { return strings.Join(r.Passengers, "; ") }},
+	{Name: "ticketNumber", Description: "ticket numbers, separated by semicolons", value: func(r Row) string { return strings.Join(r.TicketNumbers, "; ") }},
+	{Name: "reservation", Description: "booking reference (PNR)", value: func(r Row) string { return r.Reservation }},
+	{Name: "route", Description: "airports of segments, e.g. SIN-DPS-SIN", value: func(r Row) string { return r.Route }},
+	{Name: "departureDate", Description: "date of the first flight", value: func(r Row) string { return r.DepartureDate }},
+	{Name: "returnDate", Description: "date of the last flight", value: func(r Row) string { return r.ReturnDate }},
+	{Name: "date", Description: "date of issue, or of the first flight", value: func(r Row) string { return firstOf(r.IssueDate, r.DepartureDate) }},
+	{Name: "issueDate", Description: "date ticket was issued", value: func(r Row) string { return r.IssueDate }},
+	{Name: "airline", Description: "airline of the first flight, the merchant", value: func(r Row) string { return r.Airline }},
+	{Name: "currency", Description: "ISO 4217 code of amounts", value: func(r Row) string { return r.Currency }},
+	{Name: "fare", Description: "fare without taxes", value: func(r Row) string { return amount(r.Fare, r.Currency) }},
+	{Name: "taxes", Description: "taxes, fees and surcharges", value: func(r Row) string { return amount(r.Taxes, r.Currency) }},
+	{Name: "total", Description: "amount paid", value: func(r Row) string { return amount(r.Total, r.Currency) }},
+	{Name: "miles", Description: "miles paid", value: func(r Row) string { return number(r.Miles) }},
+	{Name: "category", Description: "expense category, always " + category, value: func(r Row) string { return category }},
+	{Name: "description", Description: "route, dates and booking reference", value: description},
+}
+
+// FieldByName returns field by name, names are case insensitive
+func FieldByName(name string) (Field, bool) {
+	for _, f := range Fields {
+		if strings.EqualFold(f.Name, strings.TrimSpace(name)) {
+			return f, true
+		}
+	}
+
+	return Field{}, false
+}
+
+// FieldNames lists names of fields
+func FieldNames() []string {
+	names := make([]string, 0, len(Fields))
+	for _, f := range Fields {
+		names = append(names, f.Name)
+	}
+
+	return names
+}
+
+// Column is a column of report, a field under its header
+type Column struct {
+	Header string
+	Field  Field
+}
+
+// Profile is a layout of columns an expense tool imports
+type Profile struct {
+	Name    string
+	Columns []Column
+}
+
+// Header returns headers of columns
+func (p Profile) Header() []string {
+	header := make([]string, 0, len(p.Columns))
+	for _, c := range p.Columns {
+		header = append(header, c.Header)
+	}
+
+	return header
+}
+
+// Values returns values of row in order of columns
+func (p Profile) Values(row Row) []string {
+	values := make([]string, 0, len(p.Columns))
+	for _, c := range p.Columns {
+		values = append(values, c.Field.value(row))
+	}
+
+	return values
+}
+
+// Default profile has a column of every field, headers are names of fields
+var Default = Profile{Name: "default", Columns: columns(
+	"document", "document", "passenger", "passenger", "ticketNumber", "ticketNumber", "reservation", "reservation",
+	"route", "route", "departureDate", "departureDate", "returnDate", "returnDate", "airline", "airline",
+	"issueDate", "issueDate", "currency", "currency", "fare", "fare", "taxes", "taxes", "total", "total", "miles", "miles",
+)}
+
+// Profiles of expense tools, their CSV imports expect these headers
+var Profiles = []Profile{
+	Default,
+	{Name: "expensify", Columns: columns(
+		"date", "Date", "airline", "Merchant", "total", "Amount", "currency", "Currency", "category", "Category",
+		"description", "Comment",
+	)},
+	{Name: "concur", Columns: columns(
+		"date", "Transaction Date", "category", "Expense Type", "airline", "Vendor", "total", "Amount",
+		"currency", "Currency", "passenger", "Passenger", "ticketNumber", "Ticket Number", "reservation", "Record Locator",
+		"route", "Itinerary", "departureDate", "Departure Date", "returnDate", "Return Date", "description", "Comment",
+	)},
+}
+
+// ProfileNames lists names of profiles
+func ProfileNames() []string {
+	names := make([]string, 0, len(Profiles))
+	for _, p := range Profiles {
+		names = append(names, p.Name)
+	}
+
+	return names
+}
+
+// ProfileByName returns profile by name, empty name is the default profile
+func ProfileByName(name string) (Profile, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return Default, true
+	}
+
+	for _, p := range Profiles {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	return Profile{}, false
+}
+
+// Mapping returns profile of name, with columns replaced by mapping if it is given. Mapping lists columns
+// as field:Header separated by commas, e.g. total:Amount,currency:Currency, header defaults to name of field.
+func Mapping(name, mapping string) (Profile, error) {
+	profile, found := ProfileByName(name)
+	if !found {
+		return Profile{}, fmt.Errorf("unknown expense profile %q, profiles are %s", name, strings.Join(ProfileNames(), ", "))
+	}
+
+	if strings.TrimSpace(mapping) == "" {
+		return profile, nil
+	}
+
+	profile.Columns = make([]Column, 0)
+	for _, column := range strings.Split(mapping, ",") {
+		if strings.TrimSpace(column) == "" {
+			continue
+		}
+
+		name, header := column, ""
+		if i := strings.IndexByte(column, ':'); i != -1 {
+			name, header = column[:i], strings.TrimSpace(column[i+1:])
+		}
+
+		f, found := FieldByName(name)
+		if !found {
+			return Profile{}, fmt.Errorf("unknown expense field %q, fields are %s", strings.TrimSpace(name), strings.Join(FieldNames(), ", "))
+		}
+
+		profile.Columns = append(profile.Columns, Column{Header: firstOf(header, f.Name), Field: f})
+	}
+
+	if len(profile.Columns) == 0 {
+		return Profile{}, fmt.Errorf("no expense columns in %q", mapping)
+	}
+
+	profile.Name = "custom"
+	return profile, nil
+}
+
+// columns pairs names of fields with headers, fields are known so unknown names panic
+func columns(pairs ...string) []Column {
+	result := make([]Column, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		f, found := FieldByName(pairs[i])
+		if !found {
+			panic("unknown expense field " + pairs[i])
+		}
+		result = append(result, Column{Header: pairs[i+1], Field: f})
+	}
+
+	return result
+}
+
+// description summarizes row for comments of expenses, e.g. Flight SIN-DPS-SIN 2022-11-29 to 2022-12-05, PNR 6GIY5Q
+func description(r Row) string {
+	parts := make([]string, 0, 3)
+
+	flight := strings.TrimSpace("Flight " + r.Route)
+	switch {
+	case r.DepartureDate != "" && r.ReturnDate != "" && r.ReturnDate != r.DepartureDate:
+		flight += " " + r.DepartureDate + " to " + r.ReturnDate
+	case r.DepartureDate != "":
+		flight += " " + r.DepartureDate
+	}
+	parts = append(parts, flight)
+
+	if r.Reservation != "" {
+		parts = append(parts, "PNR "+r.Reservation)
+	}
+	if r.Miles > 0 {
+		parts = append(parts, strconv.Itoa(r.Miles)+" miles")
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// amount formats amount with minor units of its currency, zero amounts are empty
+func amount(value float64, code string) string {
+	if value == 0 {
+		return ""
+	}
+
+	digits := 2
+	if c, found := currency.Get(code); found {
+		digits = c.DecimalDigits
+	}
+
+	return strconv.FormatFloat(value, 'f', digits, 64)
+}
+
+func number(value int) string {
+	if value == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(value)
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// sorted returns values sorted without duplicates
+func sorted(values map[string]bool) []string {
+	result := make([]string, 0, len(values))
+	for v := range values {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+
+	return result
+}
@@ -0,0 +1,86 @@
+package expense
+
+import (
+	"sort"
+
+	"trikliq-airport-finder/pkg/currency"
+)
+
+// Summary sums up a batch of documents, amounts are totalled per currency since they can not be added up
+type Summary struct {
+	Documents  int      `json:"documents"`
+	Receipts   int      `json:"receipts"`
+	Rejected   int      `json:"rejected"`
+	Totals     []Total  `json:"totals"`
+	Miles      int      `json:"miles,omitempty"`
+	Passengers []string `json:"passengers"`
+	From       string   `json:"from,omitempty"`
+	To         string   `json:"to,omitempty"`
+}
+
+// Total of receipts paid in one currency
+type Total struct {
+	Currency string  `json:"currency"`
+	Receipts int     `json:"receipts"`
+	Fare     float64 `json:"fare"`
+	Taxes    float64 `json:"taxes"`
+	Total    float64 `json:"total"`
+}
+
+// Summarize sums up rows of receipts of batch, documents counts every document and rejected those with errors
+func Summarize(rows []Row, documents, rejected int) Summary {
+	var (
+		summary = Summary{
+			Documents: documents,
+			Receipts:  len(rows),
+			Rejected:  rejected,
+			Totals:    make([]Total, 0),
+		}
+		totals     = make(map[string]*Total)
+		passengers = make(map[string]bool)
+	)
+
+	for _, r := range rows {
+		t, found := totals[r.Currency]
+		if !found {
+			t = &Total{Currency: r.Currency}
+			totals[r.Currency] = t
+		}
+		t.Receipts++
+		t.Fare += r.Fare
+		t.Taxes += r.Taxes
+		t.Total += r.Total
+
+		summary.Miles += r.Miles
+		for _, p := range r.Passengers {
+			passengers[p] = true
+		}
+
+		// dates are 2006-01-02, so they compare as strings
+		for _, date := range []string{r.DepartureDate, r.ReturnDate} {
+			if date == "" {
+				continue
+			}
+			if summary.From == "" || date < summary.From {
+				summary.From = date
+			}
+			if date > summary.To {
+				summary.To = date
+			}
+		}
+	}
+
+	for _, t := range totals {
+		if c, found := currency.Get(t.Currency); found {
+			t.Fare, t.Taxes, t.Total = c.Round(t.Fare), c.Round(t.Taxes), c.Round(t.Total)
+		}
+		summary.Totals = append(summary.Totals, *t)
+	}
+	sort.Slice(summary.Totals, func(i, j int) bool {
+		return summary.Totals[i].Currency < summary.Totals[j].Currency
+	})
+
+	summary.Passengers = sorted(passengers)
+
+	return summary
+}
@@ -0,0 +1,91 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+)
+
+// csvHeader of rows, a row per segment
+var csvHeader = []string{
+	"document", "segment", "flightNumber", "reservation", "departure", "arrival",
+	"departureCountry", "arrivalCountry", "date", "departureTime", "arrivalTime", "blockMinutes", "overnight",
+	"departureTerminal", "arrivalTerminal", "gate", "classification", "distanceKm", "co2Kg",
+	"passengers", "seats", "cabins", "source", "confidence",
+}
+
+// encodeCSV writes a row per segment of every document, documents without segments have no rows.
+// Passengers of segment are joined by semicolons, seats and cabins follow order of passengers.
+func encodeCSV(w io.Writer, result Result, _ Options) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, d := range result.Documents {
+		if d.Itinerary == nil {
+			continue
+		}
+
+		for i, s := range d.Itinerary.Segments {
+			passengers, seats, cabins := segmentPassengers(*d.Itinerary, i)
+
+			co2 := ""
+			if s.CO2 != nil {
+				co2 = decimal(s.CO2.Kg)
+			}
+
+			row := []string{
+				d.Name, strconv.Itoa(i), s.FlightNumber, s.Reservation, s.Departure, s.Arrival,
+				s.DepartureCountry, s.ArrivalCountry, s.Date, s.DepartureTime, s.ArrivalTime, number(s.BlockMinutes), strconv.FormatBool(s.Overnight),
+				s.DepartureTerminal, s.ArrivalTerminal, s.Gate, s.Classification, decimal(s.DistanceKm), co2,
+				strings.Join(passengers, ";"), strings.Join(seats, ";"), strings.Join(cabins, ";"), s.Source, decimal(s.Confidence),
+			}
+
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// segmentPassengers returns names of passengers on segment with their seats and cabins
+func segmentPassengers(itinerary model.Itinerary, index int) (names, seats, cabins []string) {
+	for _, p := range itinerary.Passengers {
+		seat, cabin := "", ""
+		for _, s := range p.Segments {
+			if s.Segment == index {
+				seat, cabin = s.Seat, s.Cabin
+			}
+		}
+
+		names = append(names, p.Name)
+		seats = append(seats, seat)
+		cabins = append(cabins, cabin)
+	}
+
+	return
+}
+
+// number leaves zero values empty
+func number(value int) string {
+	if value == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(value)
+}
+
+func decimal(value float64) string {
+	if value == 0 {
+		return ""
+	}
+
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
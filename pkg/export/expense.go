@@ -0,0 +1,23 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// encodeExpense writes a row per receipt in columns of expense profile, documents without receipt have no rows
+func encodeExpense(w io.Writer, result Result, options Options) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(options.Expense.Header()); err != nil {
+		return err
+	}
+
+	for _, row := range result.Expenses() {
+		if err := writer.Write(options.Expense.Values(row)); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
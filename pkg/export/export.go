@@ -0,0 +1,340 @@
+// Package export writes read itineraries in formats partners consume, all of them from the same itinerary model
+package export
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/expense"
+	"trikliq-airport-finder/pkg/ics"
+	"trikliq-airport-finder/pkg/maps"
+)
+
+var (
+	// ErrNoFlights is returned by formats of flights if no document has a dated flight
+	ErrNoFlights = errors.New("no dated flights to export")
+)
+
+// Result is what was read from request, every document with either its itinerary or error
+type Result struct {
+	Status    bool       `json:"status"`
+	Errors    []string   `json:"errors"`
+	Documents []Document `json:"documents"`
+	// Summary of batches of several documents, it is added when result is encoded
+	Summary *expense.Summary `json:"summary,omitempty"`
+}
+
+// Document is itinerary read from file or part of it, rejected documents keep their classification
+type Document struct {
+	Name      string           `json:"name"`
+	Error     string           `json:"error,omitempty"`
+	Itinerary *model.Itinerary `json:"itinerary,omitempty"`
+}
+
+// NewResult returns result without documents
+func NewResult() Result {
+	return Result{Status: true, Documents: make([]Document, 0)}
+}
+
+// Add adds document, err is recorded as error of request too
+func (r *Result) Add(name string, itinerary model.Itinerary, err error) {
+	document := Document{Name: name, Itinerary: &itinerary}
+	if err != nil {
+		document.Error = err.Error()
+		r.fail(fmt.Sprintf("%s: %s", name, err))
+	}
+
+	r.Documents = append(r.Documents, document)
+}
+
+// Fail adds document which could not be read at all
+func (r *Result) Fail(name string, err error) {
+	r.Documents = append(r.Documents, Document{Name: name, Error: err.Error()})
+	r.fail(err.Error())
+}
+
+func (r *Result) fail(message string) {
+	r.Errors = append(r.Errors, message)
+	r.Status = false
+}
+
+// Itineraries returns itineraries of documents in order
+func (r Result) Itineraries() []model.Itinerary {
+	itineraries := make([]model.Itinerary, 0, len(r.Documents))
+	for _, d := range r.Documents {
+		if d.Itinerary != nil {
+			itineraries = append(itineraries, *d.Itinerary)
+		}
+	}
+
+	return itineraries
+}
+
+// Expenses returns receipts of documents as expenses, rejected documents have none
+func (r Result) Expenses() []expense.Row {
+	rows := make([]expense.Row, 0, len(r.Documents))
+	for _, d := range r.Documents {
+		if d.Itinerary == nil || d.Error != "" {
+			continue
+		}
+		if row, found := expense.NewRow(d.Name, *d.Itinerary); found {
+			rows = append(rows, row)
+		}
+	}
+
+	return rows
+}
+
+// Summarize sums up receipts of documents
+func (r Result) Summarize() expense.Summary {
+	rejected := 0
+	for _, d := range r.Documents {
+		if d.Error != "" {
+			rejected++
+		}
+	}
+
+	return expense.Summarize(r.Expenses(), len(r.Documents), rejected)
+}
+
+// Response returns result the way /read always responded, itineraries keyed by names of documents
+func (r Result) Response() model.Response {
+	data := make(map[string]any, len(r.Documents))
+	for _, d := range r.Documents {
+		if d.Itinerary != nil {
+			data[d.Name] = d.Itinerary
+		}
+	}
+
+	response := model.Response{Status: r.Status, Errors: r.Errors, Data: data}
+
+	// typed nil would be encoded as null
+	if r.Summary != nil {
+		response.Summary = r.Summary
+	}
+
+	return response
+}
+
+// Format is an output format, chosen by name or by media type
+type Format struct {
+	Name      string
+	MimeType  string
+	Extension string
+	// Attachment formats are files rather than responses, e.g. calendars
+	Attachment bool
+
+	aliases []string
+	encode  func(w io.Writer, result Result, options Options) error
+}
+
+// Options of encoding, formats take what they need
+type Options struct {
+	Registry *airport.Registry
+	// Expense is profile of expense format, zero value is the default profile
+	Expense expense.Profile
+	// Indent indents JSON
+	Indent bool
+}
+
+// ContentType is media type of format with charset
+func (f Format) ContentType() string {
+	return f.MimeType + "; charset=utf-8"
+}
+
+// Encode writes result in format, batches of several documents are summarized
+func (f Format) Encode(w io.Writer, result Result, options Options) error {
+	if len(result.Documents) > 1 {
+		summary := result.Summarize()
+		result.Summary = &summary
+	}
+	if len(options.Expense.Columns) == 0 {
+		options.Expense = expense.Default
+	}
+
+	return f.encode(w, result, options)
+}
+
+// JSON is the default format, it comes first so wildcards match it
+var JSON = Format{Name: "json", MimeType: "application/json", Extension: "json", encode: encodeJSON}
+
+// Formats in order of preference
+var Formats = []Format{
+	JSON,
+	{Name: "ndjson", MimeType: "application/x-ndjson", Extension: "ndjson", aliases: []string{"application/ndjson", "application/jsonl"}, encode: encodeNDJSON},
+	{Name: "csv", MimeType: "text/csv", Extension: "csv", Attachment: true, encode: encodeCSV},
+	{Name: "expense", MimeType: "text/csv", Extension: "csv", Attachment: true, encode: encodeExpense},
+	{Name: "xml", MimeType: "application/xml", Extension: "xml", aliases: []string{"text/xml"}, encode: encodeXML},
+	{Name: "yaml", MimeType: "application/yaml", Extension: "yaml", aliases: []string{"application/x-yaml", "text/yaml", "text/x-yaml"}, encode: encodeYAML},
+	{Name: "geojson", MimeType: "application/geo+json", Extension: "geojson", encode: encodeGeoJSON},
+	{Name: "jsonld", MimeType: "application/ld+json", Extension: "jsonld", encode: encodeJSONLD},
+	{Name: "svg", MimeType: maps.MimeType, Extension: "svg", encode: encodeSVG},
+	{Name: "ics", MimeType: ics.MimeType, Extension: "ics", Attachment: true, encode: encodeICS},
+}
+
+// Names lists names of formats
+func Names() []string {
+	names := make([]string, 0, len(Formats))
+	for _, f := range Formats {
+		names = append(names, f.Name)
+	}
+
+	return names
+}
+
+// ByName returns format by name, e.g. ?format=csv
+func ByName(name string) (Format, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, f := range Formats {
+		if f.Name == name {
+			return f, true
+		}
+	}
+
+	return Format{}, false
+}
+
+// Negotiate picks format for Accept header, media types are tried by quality and then in order they are listed.
+// Wildcards skip formats refused with q=0. Missing header means JSON, and so does a wildcard of JSON accepted as
+// much as the named format. Browsers accept XML next to HTML for pages, their XML is ignored. ok is false if no
+// format is acceptable.
+func Negotiate(accept string) (Format, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return JSON, true
+	}
+
+	type accepted struct {
+		mediaType string
+		quality   float64
+	}
+
+	var (
+		ranges  = make([]accepted, 0)
+		refused = make(map[string]bool)
+		browser bool
+		// wildcard is the highest quality of ranges matching JSON by wildcard
+		wildcard float64
+	)
+	for _, value := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := params["q"]; found {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+		if quality <= 0 {
+			if f, found := byMediaType(mediaType, nil); found {
+				refused[f.Name] = true
+			}
+			continue
+		}
+
+		switch mediaType {
+		case "text/html":
+			browser = true
+		case "*/*", "application/*":
+			wildcard = math.Max(wildcard, quality)
+		}
+
+		ranges = append(ranges, accepted{mediaType: mediaType, quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, r := range ranges {
+		f, found := byMediaType(r.mediaType, refused)
+		if !found || (browser && f.Name == "xml" && !strings.HasSuffix(r.mediaType, "/*")) {
+			continue
+		}
+		if !refused[JSON.Name] && wildcard >= r.quality {
+			return JSON, true
+		}
+
+		return f, true
+	}
+
+	return Format{}, false
+}
+
+// byMediaType matches media range, wildcards match the first format which is not refused
+func byMediaType(mediaType string, refused map[string]bool) (Format, bool) {
+	for _, f := range Formats {
+		if f.MimeType == mediaType {
+			return f, true
+		}
+		for _, alias := range f.aliases {
+			if alias == mediaType {
+				return f, true
+			}
+		}
+	}
+
+	if !strings.HasSuffix(mediaType, "/*") {
+		return Format{}, false
+	}
+
+	prefix := strings.TrimSuffix(mediaType, "*")
+	for _, f := range Formats {
+		if !refused[f.Name] && (prefix == "*/" || strings.HasPrefix(f.MimeType, prefix)) {
+			return f, true
+		}
+	}
+
+	return Format{}, false
+}
+
+func encodeJSON(w io.Writer, result Result, options Options) error {
+	encoder := json.NewEncoder(w)
+	if options.Indent {
+		encoder.SetIndent("", "\t")
+	}
+
+	return encoder.Encode(result.Response())
+}
+
+// encodeNDJSON writes a line per document
+func encodeNDJSON(w io.Writer, result Result, _ Options) error {
+	encoder := json.NewEncoder(w)
+	for _, d := range result.Documents {
+		if err := encoder.Encode(d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func encodeICS(w io.Writer, result Result, options Options) error {
+	calendar := ics.Itineraries(result.Itineraries(), options.Registry)
+	if len(calendar.Events) == 0 {
+		return ErrNoFlights
+	}
+
+	return calendar.Encode(w)
+}
+
+// encodeSVG draws segments of all documents on one map, maps missing any airport are refused
+func encodeSVG(w io.Writer, result Result, options Options) error {
+	m := maps.Itineraries("Itinerary", result.Itineraries(), options.Registry)
+	if err := m.Complete(); err != nil {
+		return err
+	}
+
+	return m.Encode(w)
+}
@@ -0,0 +1,36 @@
+package export
+
+import "testing"
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   string
+	}{
+		{"", "json"},
+		{"*/*", "json"},
+		// browsers and fetch get the default
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "json"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7", "json"},
+		{"application/xml", "xml"},
+		{"text/xml;q=0.5", "xml"},
+		{"text/csv", "csv"},
+		{"application/xml, */*;q=0.8", "xml"},
+		// a wildcard accepted as much as the named format keeps JSON
+		{"text/csv, */*", "json"},
+		{"application/yaml;q=0.5, application/*;q=0.5", "json"},
+		{"application/json;q=0, */*", "ndjson"},
+		{"text/*", "csv"},
+	}
+
+	for _, c := range cases {
+		f, ok := Negotiate(c.accept)
+		if !ok || f.Name != c.want {
+			t.Errorf("%q: got %q, %v, want %q", c.accept, f.Name, ok, c.want)
+		}
+	}
+
+	if f, ok := Negotiate("image/png"); ok {
+		t.Errorf("got %q for image/png, want none", f.Name)
+	}
+}
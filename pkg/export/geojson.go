@@ -0,0 +1,164 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/geo"
+)
+
+const (
+	// stepKm is length of straight pieces of great-circle paths
+	stepKm   = 100
+	maxSteps = 128
+)
+
+type featureCollection struct {
+	Type     string    `json:"type"`
+	Features []feature `json:"features"`
+}
+
+type feature struct {
+	Type       string   `json:"type"`
+	Geometry   geometry `json:"geometry"`
+	Properties any      `json:"properties"`
+}
+
+type geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+type airportProperties struct {
+	IATA    string `json:"iata"`
+	ICAO    string `json:"icao,omitempty"`
+	Name    string `json:"name,omitempty"`
+	City    string `json:"city,omitempty"`
+	Country string `json:"country,omitempty"`
+	Tz      string `json:"tz,omitempty"`
+}
+
+type segmentProperties struct {
+	Document      string  `json:"document"`
+	Segment       int     `json:"segment"`
+	FlightNumber  string  `json:"flightNumber,omitempty"`
+	Reservation   string  `json:"reservation,omitempty"`
+	Departure     string  `json:"departure"`
+	Arrival       string  `json:"arrival"`
+	Date          string  `json:"date,omitempty"`
+	DepartureTime string  `json:"departureTime,omitempty"`
+	ArrivalTime   string  `json:"arrivalTime,omitempty"`
+	DistanceKm    float64 `json:"distanceKm,omitempty"`
+}
+
+// encodeGeoJSON writes airports of all documents as points and their segments as great-circle paths.
+// Paths crossing the antimeridian are split in two, as RFC 7946 asks. Fails with airport.ErrNoCoordinates
+// if registry has no coordinates.
+func encodeGeoJSON(w io.Writer, result Result, options Options) error {
+	// an empty collection would look like a trip without flights
+	if !options.Registry.HasCoordinates() {
+		return airport.ErrNoCoordinates
+	}
+
+	var (
+		collection = featureCollection{Type: "FeatureCollection", Features: make([]feature, 0)}
+		airports   = make([]feature, 0)
+		segments   = make([]feature, 0)
+		seen       = make(map[string]bool)
+	)
+
+	point := func(code string) (lat, lon float64, ok bool) {
+		a, found := options.Registry.IATA(code)
+		if !found {
+			return
+		}
+
+		// airports of several segments are points once
+		lat, lon, ok = a.Coordinates()
+		if !ok || seen[a.IATA] {
+			return
+		}
+		seen[a.IATA] = true
+
+		airports = append(airports, feature{
+			Type:     "Feature",
+			Geometry: geometry{Type: "Point", Coordinates: position(lat, lon)},
+			Properties: airportProperties{
+				IATA:    a.IATA,
+				ICAO:    a.ICAO,
+				Name:    a.Name,
+				City:    a.City,
+				Country: a.Country,
+				Tz:      a.Tz,
+			},
+		})
+
+		return
+	}
+
+	for _, d := range result.Documents {
+		if d.Itinerary == nil {
+			continue
+		}
+
+		for i, s := range d.Itinerary.Segments {
+			lat1, lon1, from := point(s.Departure)
+			lat2, lon2, to := point(s.Arrival)
+			if !from || !to {
+				continue
+			}
+
+			segments = append(segments, feature{
+				Type:     "Feature",
+				Geometry: path(lat1, lon1, lat2, lon2),
+				Properties: segmentProperties{
+					Document:      d.Name,
+					Segment:       i,
+					FlightNumber:  s.FlightNumber,
+					Reservation:   s.Reservation,
+					Departure:     s.Departure,
+					Arrival:       s.Arrival,
+					Date:          s.Date,
+					DepartureTime: s.DepartureTime,
+					ArrivalTime:   s.ArrivalTime,
+					DistanceKm:    s.DistanceKm,
+				},
+			})
+		}
+	}
+
+	collection.Features = append(collection.Features, airports...)
+	collection.Features = append(collection.Features, segments...)
+
+	return json.NewEncoder(w).Encode(collection)
+}
+
+// path returns great-circle path between airports as LineString, or as MultiLineString if it crosses the antimeridian
+func path(lat1, lon1, lat2, lon2 float64) geometry {
+	steps := int(geo.Haversine(lat1, lon1, lat2, lon2) / stepKm)
+	if steps > maxSteps {
+		steps = maxSteps
+	}
+
+	lines := make([][][2]float64, 0, 1)
+	for _, line := range geo.SplitAntimeridian(geo.GreatCircle(lat1, lon1, lat2, lon2, steps)) {
+		positions := make([][2]float64, 0, len(line))
+		for _, p := range line {
+			positions = append(positions, position(p[0], p[1]))
+		}
+		lines = append(lines, positions)
+	}
+
+	if len(lines) == 1 {
+		return geometry{Type: "LineString", Coordinates: lines[0]}
+	}
+
+	return geometry{Type: "MultiLineString", Coordinates: lines}
+}
+
+// position is longitude and latitude, in this order, rounded to about a metre
+func position(lat, lon float64) [2]float64 {
+	return [2]float64{math.Round(lon*1e5) / 1e5, math.Round(lat*1e5) / 1e5}
+}
@@ -0,0 +1,134 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airline"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/trip"
+)
+
+const schemaContext = "https://schema.org"
+
+type flightReservation struct {
+	Context           string       `json:"@context"`
+	Type              string       `json:"@type"`
+	ReservationNumber string       `json:"reservationNumber,omitempty"`
+	ReservationStatus string       `json:"reservationStatus"`
+	UnderName         *thing       `json:"underName,omitempty"`
+	AirplaneSeat      string       `json:"airplaneSeat,omitempty"`
+	AirplaneSeatClass *thing       `json:"airplaneSeatClass,omitempty"`
+	ReservationFor    schemaFlight `json:"reservationFor"`
+}
+
+type schemaFlight struct {
+	Type              string `json:"@type"`
+	FlightNumber      string `json:"flightNumber,omitempty"`
+	Airline           *thing `json:"airline,omitempty"`
+	DepartureAirport  *thing `json:"departureAirport"`
+	ArrivalAirport    *thing `json:"arrivalAirport"`
+	DepartureTime     string `json:"departureTime,omitempty"`
+	ArrivalTime       string `json:"arrivalTime,omitempty"`
+	DepartureTerminal string `json:"departureTerminal,omitempty"`
+	ArrivalTerminal   string `json:"arrivalTerminal,omitempty"`
+	DepartureGate     string `json:"departureGate,omitempty"`
+}
+
+// thing is a named schema.org thing, airports and airlines have IATA codes too
+type thing struct {
+	Type     string `json:"@type"`
+	Name     string `json:"name,omitempty"`
+	IATACode string `json:"iataCode,omitempty"`
+}
+
+// encodeJSONLD writes schema.org flight reservations, the markup airlines put into confirmation emails.
+// Every passenger has a reservation of every segment, segments without passengers have one reservation.
+func encodeJSONLD(w io.Writer, result Result, options Options) error {
+	reservations := make([]flightReservation, 0)
+
+	for _, itinerary := range result.Itineraries() {
+		for i, s := range itinerary.Segments {
+			flight := reservedFlight(s, options.Registry)
+
+			if len(itinerary.Passengers) == 0 {
+				reservations = append(reservations, reservation(s, flight))
+				continue
+			}
+
+			for _, p := range itinerary.Passengers {
+				r := reservation(s, flight)
+				r.UnderName = &thing{Type: "Person", Name: p.Name}
+
+				for _, details := range p.Segments {
+					if details.Segment != i {
+						continue
+					}
+
+					r.AirplaneSeat = details.Seat
+					if details.Cabin != "" {
+						r.AirplaneSeatClass = &thing{Type: "AirplaneSeatClass", Name: details.Cabin}
+					}
+				}
+
+				reservations = append(reservations, r)
+			}
+		}
+	}
+
+	return json.NewEncoder(w).Encode(reservations)
+}
+
+func reservation(segment model.Segment, flight schemaFlight) flightReservation {
+	return flightReservation{
+		Context:           schemaContext,
+		Type:              "FlightReservation",
+		ReservationNumber: segment.Reservation,
+		ReservationStatus: "https://schema.org/ReservationConfirmed",
+		ReservationFor:    flight,
+	}
+}
+
+// reservedFlight describes flight of segment, times have offsets of their airports
+func reservedFlight(segment model.Segment, registry *airport.Registry) schemaFlight {
+	flight := schemaFlight{
+		Type:              "Flight",
+		FlightNumber:      segment.FlightNumber,
+		DepartureAirport:  schemaAirport(segment.Departure, registry),
+		ArrivalAirport:    schemaAirport(segment.Arrival, registry),
+		DepartureTerminal: segment.DepartureTerminal,
+		ArrivalTerminal:   segment.ArrivalTerminal,
+		DepartureGate:     segment.Gate,
+	}
+
+	if len(segment.FlightNumber) > 2 {
+		flight.Airline = &thing{Type: "Airline", IATACode: segment.FlightNumber[:2]}
+		if a, found := airline.Get(segment.FlightNumber[:2]); found {
+			flight.Airline.Name = a.Name
+		}
+	}
+
+	departure, arrival := trip.Times(segment, registry)
+	switch {
+	case !departure.IsZero():
+		flight.DepartureTime = departure.Format(time.RFC3339)
+	case segment.Date != "":
+		flight.DepartureTime = segment.Date
+	}
+	if !arrival.IsZero() {
+		flight.ArrivalTime = arrival.Format(time.RFC3339)
+	}
+
+	return flight
+}
+
+func schemaAirport(code string, registry *airport.Registry) *thing {
+	a := &thing{Type: "Airport", IATACode: code}
+	if found, ok := registry.IATA(code); ok {
+		a.Name = found.Name
+	}
+
+	return a
+}
@@ -0,0 +1,77 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// field of object, objects keep order of their fields
+type field struct {
+	key   string
+	value any
+}
+
+// object is JSON object with its fields in order
+type object []field
+
+// tree returns value as it is encoded to JSON, so every format uses names and omissions of JSON tags.
+// Objects are objects, arrays are []any and scalars are string, json.Number, bool or nil.
+func tree(value any) (any, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+
+	return node(decoder)
+}
+
+func node(decoder *json.Decoder) (any, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			o := make(object, 0)
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+
+				value, err := node(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				o = append(o, field{key: key.(string), value: value})
+			}
+			_, err = decoder.Token()
+			return o, err
+
+		case '[':
+			a := make([]any, 0)
+			for decoder.More() {
+				value, err := node(decoder)
+				if err != nil {
+					return nil, err
+				}
+
+				a = append(a, value)
+			}
+			_, err = decoder.Token()
+			return a, err
+		}
+
+		return nil, fmt.Errorf("export: unexpected %s", t)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,100 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// items names elements of arrays which are not plurals
+var items = map[string]string{
+	"departing":     "airport",
+	"arriving":      "airport",
+	"frequentFlyer": "program",
+	"provenance":    "value",
+}
+
+// encodeXML writes result as element of its JSON fields, elements of arrays are named after the array,
+// e.g. segments are segment elements
+func encodeXML(w io.Writer, result Result, _ Options) error {
+	root, err := tree(result)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "\t")
+
+	if err := element(encoder, "result", root); err != nil {
+		return err
+	}
+	if err := encoder.Flush(); err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// element writes value of tree as element, nulls are left out
+func element(encoder *xml.Encoder, name string, value any) error {
+	if value == nil {
+		return nil
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case object:
+		for _, f := range v {
+			if err := element(encoder, f.key, f.value); err != nil {
+				return err
+			}
+		}
+
+	case []any:
+		item := item(name)
+		for _, e := range v {
+			if err := element(encoder, item, e); err != nil {
+				return err
+			}
+		}
+
+	case string:
+		if err := encoder.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+
+	case json.Number, bool:
+		if err := encoder.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+
+	return encoder.EncodeToken(start.End())
+}
+
+// item is name of element of array, singular of its name
+func item(name string) string {
+	if singular, found := items[name]; found {
+		return singular
+	}
+
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+
+	return "item"
+}
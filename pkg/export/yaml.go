@@ -0,0 +1,52 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"gopkg.in/yaml.v2"
+)
+
+// encodeYAML writes result with fields in order of JSON
+func encodeYAML(w io.Writer, result Result, _ Options) error {
+	root, err := tree(result)
+	if err != nil {
+		return err
+	}
+
+	raw, err := yaml.Marshal(yamlValue(root))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(raw)
+	return err
+}
+
+// yamlValue converts tree to ordered maps of yaml, numbers stay numbers
+func yamlValue(value any) any {
+	switch v := value.(type) {
+	case object:
+		m := make(yaml.MapSlice, 0, len(v))
+		for _, f := range v {
+			m = append(m, yaml.MapItem{Key: f.key, Value: yamlValue(f.value)})
+		}
+		return m
+
+	case []any:
+		a := make([]any, 0, len(v))
+		for _, e := range v {
+			a = append(a, yamlValue(e))
+		}
+		return a
+
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+
+	return value
+}
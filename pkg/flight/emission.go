@@ -0,0 +1,65 @@
+package flight
+
+import "math"
+
+// Emission estimates CO2 of a single passenger on a flight.
+//
+// Method follows UK Government (DEFRA/DESNZ 2023) greenhouse gas conversion
+// factors for passenger air travel, average passenger class, including the
+// effect of radiative forcing:
+//   - distance band picks the factor: domestic (< 500 km), short-haul (< 3700 km), long-haul
+//   - estimate is great-circle distance multiplied by the band factor, in kg CO2e
+//
+// Factors already include the 8% uplift for indirect routing and stacking, so distance is not uplifted again.
+type Emission struct {
+	Method     string  `json:"method"`
+	Band       string  `json:"band"`
+	Factor     float64 `json:"factor"`
+	DistanceKm float64 `json:"distanceKm"`
+	Kg         float64 `json:"kg"`
+}
+
+const EmissionMethod = "DEFRA 2023, average passenger, with radiative forcing"
+
+// emissionBand is a distance band with its kg CO2e per passenger-km factor
+type emissionBand struct {
+	name   string
+	upToKm float64
+	factor float64
+}
+
+var emissionBands = []emissionBand{
+	{name: "domestic", upToKm: 500, factor: 0.27258},
+	{name: "short-haul", upToKm: 3700, factor: 0.18592},
+	{name: "long-haul", upToKm: math.Inf(1), factor: 0.26128},
+}
+
+// CO2 estimates emission per passenger for a great-circle distance in kilometres
+func CO2(distanceKm float64) (emission Emission) {
+	if distanceKm <= 0 {
+		return
+	}
+
+	band := emissionBands[len(emissionBands)-1]
+	for _, b := range emissionBands {
+		if distanceKm < b.upToKm {
+			band = b
+			break
+		}
+	}
+
+	emission = Emission{
+		Method:     EmissionMethod,
+		Band:       band.name,
+		Factor:     band.factor,
+		DistanceKm: round(distanceKm),
+		Kg:         round(distanceKm * band.factor),
+	}
+
+	return
+}
+
+// round keeps one decimal
+func round(value float64) float64 {
+	return math.Round(value*10) / 10
+}
@@ -0,0 +1,61 @@
+package flight
+
+import "fmt"
+
+const (
+	PlausibilityUnknown     = "unknown"
+	PlausibilityPlausible   = "plausible"
+	PlausibilityImplausible = "implausible"
+)
+
+const (
+	// cruiseKmh is a typical jet cruise speed used to estimate block time
+	cruiseKmh = 780.0
+	// fastestKmh is a ground speed not reached by airliners even with strong tailwind
+	fastestKmh = 1000.0
+	// taxiMinutes is typical time spent on the ground between off-block and take-off plus landing and on-block
+	taxiMinutes = 30.0
+)
+
+// Plausibility is a result of comparing block time with distance
+type Plausibility struct {
+	Status          string `json:"status"`
+	Reason          string `json:"reason,omitempty"`
+	ExpectedMinutes int    `json:"expectedMinutes,omitempty"`
+}
+
+// ExpectedMinutes estimates block time of a flight over distance in kilometres
+func ExpectedMinutes(distanceKm float64) int {
+	return int(taxiMinutes + distanceKm/cruiseKmh*60)
+}
+
+// Check compares block time in minutes against great-circle distance in kilometres,
+// impossible pairings usually mean departure and arrival were matched to a wrong flight
+func Check(distanceKm float64, blockMinutes int) (plausibility Plausibility) {
+	plausibility.Status = PlausibilityUnknown
+
+	if distanceKm <= 0 || blockMinutes <= 0 {
+		return
+	}
+
+	expected := ExpectedMinutes(distanceKm)
+	plausibility.ExpectedMinutes = expected
+
+	var (
+		shortest = int(distanceKm / fastestKmh * 60)
+		longest  = expected*2 + 90
+	)
+
+	switch {
+	case blockMinutes < shortest:
+		plausibility.Status = PlausibilityImplausible
+		plausibility.Reason = fmt.Sprintf("%d minutes is too short to fly %.0f km", blockMinutes, distanceKm)
+	case blockMinutes > longest:
+		plausibility.Status = PlausibilityImplausible
+		plausibility.Reason = fmt.Sprintf("%d minutes is too long to fly %.0f km non-stop", blockMinutes, distanceKm)
+	default:
+		plausibility.Status = PlausibilityPlausible
+	}
+
+	return
+}
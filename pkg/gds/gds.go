@@ -0,0 +1,230 @@
+// Package gds reads air segments of PNR displays of global distribution systems, such as Amadeus, Sabre and Galileo
+package gds
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	// Amadeus   1  SQ 938 Y 12MAR 7 SINDPS HK1  0930 1225  12MAR  E  SQ/6GIY5Q
+	// Sabre     1 SQ 938Y 12MAR Q SINDPS HK1   930A 1225P /DCSQ*6GIY5Q /E
+	// Galileo   1. SQ  938 Y  12MAR SINDPS HK1   930A 1225P O*  SU  E
+	segmentRegex = regexp.MustCompile(`(?m)^[ \t]*(\d{1,2})\.?[ \t]+` + // segment number
+		`([A-Z][A-Z0-9]|[0-9][A-Z])[ \t]{0,2}(\d{1,4})[ \t]?([A-Z])[ \t]+` + // carrier, flight and booking class
+		`(\d{1,2})([A-Z]{3})(\d{2})?[ \t]+` + // date, year is rare
+		`(?:([1-7]|[A-Z]{1,2})[ \t]+)?` + // day of week, numbered from Monday or letter codes
+		`([A-Z]{3})[ \t]?([A-Z]{3})\*?[ \t]+` + // city pair
+		`([A-Z]{2})(\d{1,2})[ \t]+` + // status and number of seats
+		`(\d{3,4}[APNM]?)[ \t]+(\d{3,4}[APNM]?)` + // local departure and arrival times
+		`(?:[ \t]?[+#¥‡/*][ \t]?(\d))?` + // day change of arrival
+		`(?:[ \t]+(\d{1,2}[A-Z]{3})\b)?`) // date of arrival
+
+	// airline record locator after times, SQ/6GIY5Q of Amadeus or /DCSQ*6GIY5Q of Sabre
+	locatorRegex = regexp.MustCompile(`(?:/DC|\b)([A-Z0-9]{2})[/*]([A-Z0-9]{6})\b`)
+)
+
+// cancelled statuses of segments, e.g. cancelled by airline or unable to confirm
+var cancelled = map[string]bool{
+	"HX": true, "UN": true, "UC": true, "NO": true, "XX": true, "XK": true, "XL": true,
+}
+
+// Segment is an air segment line of PNR display
+type Segment struct {
+	Number       int    `json:"number"`
+	Carrier      string `json:"carrier"`
+	FlightNumber string `json:"flightNumber"`
+	Class        string `json:"class"`
+	Day          int    `json:"day"`
+	Month        int    `json:"month"`
+	Year         int    `json:"year,omitempty"`
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Status       string `json:"status"`
+	Seats        int    `json:"seats"`
+	// Departure and Arrival are local times in minutes after midnight
+	Departure int `json:"departure"`
+	Arrival   int `json:"arrival"`
+	// DayChange is number of days arrival is after departure
+	DayChange int `json:"dayChange,omitempty"`
+	// Locator is record locator of operating airline, if display prints it
+	Locator string `json:"locator,omitempty"`
+}
+
+// Cancelled reports if status of segment says it was cancelled or could not be confirmed
+func (s Segment) Cancelled() bool {
+	return cancelled[s.Status]
+}
+
+// Date resolves date of segment, which usually has no year. The first matching date on or after
+// reference is used, so reference should be date the display was issued or printed.
+func (s Segment) Date(reference time.Time) (time.Time, bool) {
+	if s.Year != 0 {
+		date := time.Date(s.Year, time.Month(s.Month), s.Day, 0, 0, 0, 0, time.UTC)
+		return date, date.Day() == s.Day
+	}
+
+	y, m, d := reference.Date()
+	reference = time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	// 29FEB only exists in leap years
+	for year := reference.Year(); year <= reference.Year()+4; year++ {
+		date := time.Date(year, time.Month(s.Month), s.Day, 0, 0, 0, 0, time.UTC)
+		if date.Day() == s.Day && !date.Before(reference) {
+			return date, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// Find returns air segments of PNR displays in text in order of appearance
+func Find(txt string) []Segment {
+	segments := make([]Segment, 0)
+
+	for _, index := range segmentRegex.FindAllStringSubmatchIndex(txt, -1) {
+		match := make([]string, len(index)/2)
+		for i := range match {
+			if index[2*i] != -1 {
+				match[i] = txt[index[2*i]:index[2*i+1]]
+			}
+		}
+
+		segment, ok := parse(match)
+		if !ok {
+			continue
+		}
+
+		// locator is printed at the end of segment line
+		rest := txt[index[1]:]
+		if end := strings.IndexByte(rest, '\n'); end != -1 {
+			rest = rest[:end]
+		}
+		if locator := locatorRegex.FindStringSubmatch(rest); locator != nil {
+			segment.Locator = locator[2]
+		}
+
+		segments = append(segments, segment)
+	}
+
+	return segments
+}
+
+func parse(match []string) (segment Segment, ok bool) {
+	segment = Segment{
+		Carrier:      match[2],
+		FlightNumber: strings.TrimLeft(match[3], "0"),
+		Class:        match[4],
+		From:         match[9],
+		To:           match[10],
+		Status:       match[11],
+	}
+
+	segment.Number, _ = strconv.Atoi(match[1])
+	segment.Seats, _ = strconv.Atoi(match[12])
+
+	month, found := months[match[6]]
+	if !found {
+		return segment, false
+	}
+	segment.Month = int(month)
+
+	segment.Day, _ = strconv.Atoi(match[5])
+	if segment.Day < 1 || segment.Day > 31 {
+		return segment, false
+	}
+
+	if match[7] != "" {
+		year, _ := strconv.Atoi(match[7])
+		segment.Year = 2000 + year
+	}
+
+	if segment.Departure, ok = minutes(match[13]); !ok {
+		return
+	}
+	if segment.Arrival, ok = minutes(match[14]); !ok {
+		return
+	}
+
+	segment.DayChange, _ = strconv.Atoi(match[15])
+
+	// displays without day change indicator print date of arrival instead
+	if segment.DayChange == 0 && match[16] != "" {
+		segment.DayChange = arrivalDayChange(segment, match[16])
+	}
+
+	return segment, true
+}
+
+var months = map[string]time.Month{
+	"JAN": time.January, "FEB": time.February, "MAR": time.March, "APR": time.April,
+	"MAY": time.May, "JUN": time.June, "JUL": time.July, "AUG": time.August,
+	"SEP": time.September, "OCT": time.October, "NOV": time.November, "DEC": time.December,
+}
+
+// minutes parses time of 24-hour displays, e.g. 0930, and of 12-hour displays, e.g. 930A, 1225P, 1200N
+func minutes(value string) (int, bool) {
+	suffix := ""
+	if last := value[len(value)-1]; last < '0' || last > '9' {
+		suffix, value = string(last), value[:len(value)-1]
+	}
+
+	clock, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, false
+	}
+
+	h, m := clock/100, clock%100
+	if m > 59 {
+		return 0, false
+	}
+
+	switch suffix {
+	case "":
+		if h > 23 {
+			return 0, false
+		}
+	case "A", "M":
+		if h < 1 || h > 12 {
+			return 0, false
+		}
+		if h == 12 {
+			h = 0
+		}
+	case "P", "N":
+		if h < 1 || h > 12 {
+			return 0, false
+		}
+		if h != 12 {
+			h += 12
+		}
+	}
+
+	return h*60 + m, true
+}
+
+// arrivalDayChange counts days between departure and date of arrival, year of which is unknown
+func arrivalDayChange(segment Segment, arrival string) int {
+	day, _ := strconv.Atoi(arrival[:len(arrival)-3])
+	month, found := months[arrival[len(arrival)-3:]]
+	if !found {
+		return 0
+	}
+
+	// any leap year works, the change is a few days at most
+	departed := time.Date(2000, time.Month(segment.Month), segment.Day, 0, 0, 0, 0, time.UTC)
+	arrived := time.Date(2000, month, day, 0, 0, 0, 0, time.UTC)
+	if arrived.Before(departed) {
+		arrived = arrived.AddDate(1, 0, 0)
+	}
+
+	change := int(arrived.Sub(departed).Hours() / 24)
+	if change > 3 {
+		return 0
+	}
+
+	return change
+}
@@ -0,0 +1,139 @@
+package geo
+
+import "math"
+
+const (
+	// EarthRadiusKm is mean radius of the Earth
+	EarthRadiusKm = 6371.0088
+	// KmToMiles converts kilometres to statute miles
+	KmToMiles = 0.621371
+)
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func degrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
+
+// Haversine returns great-circle distance in kilometres between two points
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dPhi := radians(lat2 - lat1)
+	dLambda := radians(lon2 - lon1)
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Bearing returns initial great-circle bearing in degrees (0-360, clockwise from north) from first to second point
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	dLambda := radians(lon2 - lon1)
+
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// Cartesian converts coordinates to a point on the unit sphere
+func Cartesian(lat, lon float64) [3]float64 {
+	phi, lambda := radians(lat), radians(lon)
+
+	return [3]float64{
+		math.Cos(phi) * math.Cos(lambda),
+		math.Cos(phi) * math.Sin(lambda),
+		math.Sin(phi),
+	}
+}
+
+// Chord returns straight-line distance on the unit sphere matching great-circle distance in kilometres
+func Chord(km float64) float64 {
+	angle := km / EarthRadiusKm
+	if angle >= math.Pi {
+		return 2
+	}
+
+	return 2 * math.Sin(angle/2)
+}
+
+// ValidCoordinates reports if latitude and longitude are within range
+func ValidCoordinates(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// Intermediate returns point at fraction of great-circle path from first to second point
+func Intermediate(lat1, lon1, lat2, lon2, fraction float64) (lat, lon float64) {
+	phi1, lambda1 := radians(lat1), radians(lon1)
+	phi2, lambda2 := radians(lat2), radians(lon2)
+
+	distance := Haversine(lat1, lon1, lat2, lon2) / EarthRadiusKm
+	if distance == 0 {
+		return lat1, lon1
+	}
+
+	a := math.Sin((1-fraction)*distance) / math.Sin(distance)
+	b := math.Sin(fraction*distance) / math.Sin(distance)
+
+	x := a*math.Cos(phi1)*math.Cos(lambda1) + b*math.Cos(phi2)*math.Cos(lambda2)
+	y := a*math.Cos(phi1)*math.Sin(lambda1) + b*math.Cos(phi2)*math.Sin(lambda2)
+	z := a*math.Sin(phi1) + b*math.Sin(phi2)
+
+	return degrees(math.Atan2(z, math.Sqrt(x*x+y*y))), degrees(math.Atan2(y, x))
+}
+
+// GreatCircle returns points of great-circle path from first to second point as latitude and longitude pairs,
+// split into steps of equal length. Both ends are included.
+func GreatCircle(lat1, lon1, lat2, lon2 float64, steps int) [][2]float64 {
+	if steps < 1 {
+		steps = 1
+	}
+
+	points := make([][2]float64, 0, steps+1)
+	points = append(points, [2]float64{lat1, lon1})
+
+	for i := 1; i < steps; i++ {
+		lat, lon := Intermediate(lat1, lon1, lat2, lon2, float64(i)/float64(steps))
+		points = append(points, [2]float64{lat, lon})
+	}
+
+	return append(points, [2]float64{lat2, lon2})
+}
+
+// SplitAntimeridian splits path of latitude and longitude pairs where it crosses the antimeridian,
+// e.g. where longitude jumps from 179 to -179. Lines end and start at the crossing, on either edge.
+func SplitAntimeridian(points [][2]float64) [][][2]float64 {
+	if len(points) == 0 {
+		return nil
+	}
+
+	var (
+		lines   = make([][][2]float64, 0, 1)
+		current = [][2]float64{points[0]}
+	)
+
+	for i := 1; i < len(points); i++ {
+		previous, next := points[i-1], points[i]
+
+		if math.Abs(next[1]-previous[1]) > 180 {
+			// longitude of next point continued past the antimeridian, e.g. 185 instead of -175
+			edge, continued := 180.0, next[1]+360
+			if previous[1] < 0 {
+				edge, continued = -180, next[1]-360
+			}
+			lat := previous[0] + (next[0]-previous[0])*(edge-previous[1])/(continued-previous[1])
+
+			current = append(current, [2]float64{lat, edge})
+			lines = append(lines, current)
+			current = [][2]float64{{lat, -edge}}
+		}
+
+		current = append(current, next)
+	}
+
+	return append(lines, current)
+}
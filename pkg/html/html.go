@@ -0,0 +1,132 @@
+package html
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	spacesRegex     = regexp.MustCompile(`[ \t\f\r\v\x{00a0}]+`)
+	emptyLinesRegex = regexp.MustCompile(`\n{3,}`)
+)
+
+// elements whose content is never rendered
+var hidden = map[atom.Atom]bool{
+	atom.Head: true, atom.Script: true, atom.Style: true, atom.Template: true, atom.Noscript: true, atom.Title: true,
+}
+
+// elements whose text keeps its lines and spacing, e.g. PNR displays pasted into emails
+var preformatted = map[atom.Atom]bool{atom.Pre: true, atom.Textarea: true}
+
+// verbatim marks lines of preformatted text while rendering, parser replaces NUL of documents so it cannot occur
+const verbatim = "\x00"
+
+// elements which start on a new line
+var blocks = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Div: true, atom.Dl: true,
+	atom.Dt: true, atom.Dd: true, atom.Fieldset: true, atom.Footer: true, atom.Form: true, atom.H1: true,
+	atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true, atom.Header: true, atom.Hr: true,
+	atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true, atom.Pre: true, atom.Section: true,
+	atom.Table: true, atom.Tbody: true, atom.Thead: true, atom.Tfoot: true, atom.Tr: true, atom.Ul: true,
+	atom.Caption: true, atom.Center: true,
+}
+
+// Text renders visible text of HTML document. Block elements and table rows are put on their own lines
+// and table cells are separated by tabs, so values stay next to their labels as they do in PDFs. Text of
+// preformatted elements is kept as it is.
+func Text(raw []byte) (string, error) {
+	document, err := html.Parse(bytes.NewReader(raw))
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	render(&b, document, false)
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, verbatim) {
+			lines[i] = strings.TrimRight(strings.TrimPrefix(line, verbatim), " \t\r")
+			continue
+		}
+
+		cells := strings.Split(line, "\t")
+		kept := cells[:0]
+		for _, cell := range cells {
+			cell = strings.TrimSpace(spacesRegex.ReplaceAllString(cell, " "))
+			if cell != "" {
+				kept = append(kept, cell)
+			}
+		}
+		lines[i] = strings.Join(kept, "\t")
+	}
+
+	txt := emptyLinesRegex.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	return strings.TrimSpace(txt), nil
+}
+
+func render(b *strings.Builder, node *html.Node, pre bool) {
+	// lines within preformatted text stay verbatim
+	newline := "\n"
+	if pre {
+		newline += verbatim
+	}
+
+	switch node.Type {
+	case html.TextNode:
+		if pre {
+			b.WriteString(strings.ReplaceAll(node.Data, "\n", newline))
+			return
+		}
+		b.WriteString(strings.ReplaceAll(node.Data, "\n", " "))
+		return
+	case html.CommentNode, html.DoctypeNode:
+		return
+	case html.ElementNode:
+		if hidden[node.DataAtom] {
+			return
+		}
+
+		switch {
+		case node.DataAtom == atom.Br:
+			b.WriteString(newline)
+			return
+		case node.DataAtom == atom.Img:
+			// images often carry airline logos or icons with meaningful alternative text, e.g. "Departure"
+			if alt := attribute(node, "alt"); alt != "" {
+				b.WriteString(" " + alt + " ")
+			}
+			return
+		case node.DataAtom == atom.Td || node.DataAtom == atom.Th:
+			b.WriteString("\t")
+		case preformatted[node.DataAtom]:
+			b.WriteString("\n" + verbatim)
+		case blocks[node.DataAtom]:
+			b.WriteString(newline)
+		}
+	}
+
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		render(b, child, pre || preformatted[node.DataAtom])
+	}
+
+	if node.Type == html.ElementNode && (blocks[node.DataAtom] || preformatted[node.DataAtom]) {
+		b.WriteString(newline)
+	}
+}
+
+// attribute returns value of attribute of node, or empty string if it is not set
+func attribute(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+
+	return ""
+}
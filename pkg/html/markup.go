@@ -0,0 +1,165 @@
+package html
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// JSONLD returns content of every JSON-LD script of document
+func JSONLD(raw []byte) ([]string, error) {
+	document, err := html.Parse(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	scripts := make([]string, 0)
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && node.DataAtom == atom.Script &&
+			strings.EqualFold(strings.TrimSpace(attribute(node, "type")), "application/ld+json") {
+			if script := strings.TrimSpace(textContent(node)); script != "" {
+				scripts = append(scripts, script)
+			}
+			return
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(document)
+
+	return scripts, nil
+}
+
+// Microdata returns top level items of document in the shape of JSON-LD, so both can be read the same way.
+// Type of item is kept as @type, properties with a single value are not wrapped in slice.
+func Microdata(raw []byte) ([]map[string]any, error) {
+	document, err := html.Parse(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]any, 0)
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.ElementNode && hasAttribute(node, "itemscope") && !hasAttribute(node, "itemprop") {
+			items = append(items, item(node))
+		}
+
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(document)
+
+	return items, nil
+}
+
+// item reads properties of element with itemscope, nested items are properties of their own
+func item(node *html.Node) map[string]any {
+	properties := make(map[string]any)
+	if itemType := strings.Fields(attribute(node, "itemtype")); len(itemType) > 0 {
+		properties["@type"] = itemType[0]
+	}
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+
+			names := strings.Fields(attribute(child, "itemprop"))
+			if len(names) > 0 {
+				var value any = propertyValue(child)
+				if hasAttribute(child, "itemscope") {
+					value = item(child)
+				}
+
+				for _, name := range names {
+					add(properties, name, value)
+				}
+			}
+
+			// properties of nested items belong to them
+			if !hasAttribute(child, "itemscope") {
+				walk(child)
+			}
+		}
+	}
+	walk(node)
+
+	return properties
+}
+
+// add sets property, repeated properties become a slice
+func add(properties map[string]any, name string, value any) {
+	existing, found := properties[name]
+	if !found {
+		properties[name] = value
+		return
+	}
+
+	if values, ok := existing.([]any); ok {
+		properties[name] = append(values, value)
+		return
+	}
+
+	properties[name] = []any{existing, value}
+}
+
+// propertyValue is value of microdata property, taken from attribute for elements which have one
+func propertyValue(node *html.Node) string {
+	switch node.DataAtom {
+	case atom.Meta:
+		return attribute(node, "content")
+	case atom.A, atom.Area, atom.Link:
+		return attribute(node, "href")
+	case atom.Img, atom.Audio, atom.Embed, atom.Iframe, atom.Source, atom.Video:
+		return attribute(node, "src")
+	case atom.Object:
+		return attribute(node, "data")
+	case atom.Data, atom.Meter:
+		return attribute(node, "value")
+	case atom.Time:
+		if datetime := attribute(node, "datetime"); datetime != "" {
+			return datetime
+		}
+	}
+
+	return strings.TrimSpace(spacesRegex.ReplaceAllString(textContent(node), " "))
+}
+
+// textContent joins every text node below node
+func textContent(node *html.Node) string {
+	var b strings.Builder
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		if node.Type == html.TextNode {
+			b.WriteString(node.Data)
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(node)
+
+	return b.String()
+}
+
+func hasAttribute(node *html.Node, key string) bool {
+	for _, a := range node.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,208 @@
+// Package ics writes iCalendar files, RFC 5545
+package ics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	ProdID   = "-//trikliq//airport-finder//EN"
+	MimeType = "text/calendar"
+
+	// lineLength in octets, longer lines are folded
+	lineLength = 75
+
+	localLayout = "20060102T150405"
+	utcLayout   = "20060102T150405Z"
+	dateLayout  = "20060102"
+)
+
+// Event is a VEVENT, times keep their locations and are written with TZID of them
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time // zero End is omitted, event ends when it starts
+	AllDay      bool      // all day events span dates of Start and End
+	Summary     string
+	Location    string
+	Description string
+	Lat, Lon    float64
+	Geo         bool
+}
+
+// Calendar is a VCALENDAR of events, events sharing UID are written once
+type Calendar struct {
+	Events []Event
+	Stamp  time.Time
+}
+
+// New returns empty calendar stamped with current time
+func New() *Calendar {
+	return &Calendar{Stamp: time.Now().UTC()}
+}
+
+// Add adds event unless calendar already has event with the same UID
+func (c *Calendar) Add(event Event) {
+	for _, e := range c.Events {
+		if e.UID == event.UID {
+			return
+		}
+	}
+
+	c.Events = append(c.Events, event)
+}
+
+// Bytes returns encoded calendar
+func (c *Calendar) Bytes() []byte {
+	var b bytes.Buffer
+	c.Encode(&b)
+	return b.Bytes()
+}
+
+// Encode writes calendar with time zones of its events
+func (c *Calendar) Encode(w io.Writer) error {
+	l := &lines{}
+
+	l.add("BEGIN:VCALENDAR")
+	l.add("VERSION:2.0")
+	l.add("PRODID:" + ProdID)
+	l.add("CALSCALE:GREGORIAN")
+	l.add("METHOD:PUBLISH")
+
+	for _, z := range c.zones() {
+		z.encode(l)
+	}
+
+	for _, e := range c.Events {
+		l.add("BEGIN:VEVENT")
+		l.add("UID:" + escape(e.UID))
+		l.add("DTSTAMP:" + c.Stamp.UTC().Format(utcLayout))
+
+		if e.AllDay {
+			l.add("DTSTART;VALUE=DATE:" + e.Start.Format(dateLayout))
+			end := e.Start
+			if !e.End.IsZero() {
+				end = e.End
+			}
+			// end of all day events is exclusive
+			l.add("DTEND;VALUE=DATE:" + end.AddDate(0, 0, 1).Format(dateLayout))
+		} else {
+			l.add("DTSTART" + dateTime(e.Start))
+			if !e.End.IsZero() {
+				l.add("DTEND" + dateTime(e.End))
+			}
+		}
+
+		l.add("SUMMARY:" + escape(e.Summary))
+		if e.Location != "" {
+			l.add("LOCATION:" + escape(e.Location))
+		}
+		if e.Geo {
+			l.add(fmt.Sprintf("GEO:%.6f;%.6f", e.Lat, e.Lon))
+		}
+		if e.Description != "" {
+			l.add("DESCRIPTION:" + escape(e.Description))
+		}
+		l.add("TRANSP:OPAQUE")
+		l.add("END:VEVENT")
+	}
+
+	l.add("END:VCALENDAR")
+
+	_, err := w.Write(l.Bytes())
+	return err
+}
+
+// dateTime formats time as property value with its TZID parameter, UTC times need none
+func dateTime(t time.Time) string {
+	if name := t.Location().String(); name != "UTC" && name != "Local" {
+		return ";TZID=" + name + ":" + t.Format(localLayout)
+	}
+
+	return ":" + t.UTC().Format(utcLayout)
+}
+
+// zones returns time zones events refer to, with years they are used in
+func (c *Calendar) zones() []zone {
+	byName := make(map[string]*zone)
+
+	use := func(t time.Time) {
+		name := t.Location().String()
+		if t.IsZero() || name == "UTC" || name == "Local" {
+			return
+		}
+
+		z, found := byName[name]
+		if !found {
+			z = &zone{location: t.Location(), first: t.Year(), last: t.Year()}
+			byName[name] = z
+		}
+		if t.Year() < z.first {
+			z.first = t.Year()
+		}
+		if t.Year() > z.last {
+			z.last = t.Year()
+		}
+	}
+
+	for _, e := range c.Events {
+		if e.AllDay {
+			continue
+		}
+		use(e.Start)
+		use(e.End)
+	}
+
+	zones := make([]zone, 0, len(byName))
+	for _, z := range byName {
+		zones = append(zones, *z)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].location.String() < zones[j].location.String()
+	})
+
+	return zones
+}
+
+// escape escapes TEXT value
+func escape(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// lines are content lines, folded at 75 octets and ended with CRLF
+type lines struct {
+	bytes.Buffer
+}
+
+func (l *lines) add(line string) {
+	limit := lineLength
+	for len(line) > limit {
+		// runes are not split across lines
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		l.WriteString(line[:cut])
+		l.WriteString("\r\n ")
+		line = line[cut:]
+
+		// continuation lines start with a space, which counts towards their length
+		limit = lineLength - 1
+	}
+
+	l.WriteString(line)
+	l.WriteString("\r\n")
+}
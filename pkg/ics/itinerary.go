@@ -0,0 +1,167 @@
+package ics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airline"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/trip"
+)
+
+// UIDDomain ends UIDs of flight events
+const UIDDomain = "trikliq-airport-finder"
+
+// Itineraries returns calendar with an event for every dated segment of itineraries. Documents of the same
+// trip, e.g. e-ticket and boarding pass, give the same flight once.
+func Itineraries(itineraries []model.Itinerary, registry *airport.Registry) *Calendar {
+	calendar := New()
+
+	for _, itinerary := range itineraries {
+		for i := range itinerary.Segments {
+			if event, ok := SegmentEvent(itinerary, i, registry); ok {
+				calendar.Add(event)
+			}
+		}
+	}
+
+	return calendar
+}
+
+// SegmentEvent returns event of flight of segment, ok is false if the segment has no date. Segments without
+// departure time are all day events.
+func SegmentEvent(itinerary model.Itinerary, index int, registry *airport.Registry) (event Event, ok bool) {
+	segment := itinerary.Segments[index]
+
+	date, err := time.Parse("2006-01-02", segment.Date)
+	if err != nil {
+		return
+	}
+
+	departure, found := registry.IATA(segment.Departure)
+	if !found {
+		departure = &airport.Airport{IATA: segment.Departure}
+	}
+	arrival, found := registry.IATA(segment.Arrival)
+	if !found {
+		arrival = &airport.Airport{IATA: segment.Arrival}
+	}
+
+	event = Event{
+		UID:         UID(segment),
+		Summary:     summary(segment, departure, arrival),
+		Location:    location(departure, segment.DepartureTerminal),
+		Description: description(itinerary, index, departure, arrival),
+	}
+
+	start, end := trip.Times(segment, registry)
+	if start.IsZero() {
+		event.Start, event.AllDay = date, true
+	} else {
+		event.Start, event.End = start, end
+	}
+
+	event.Lat, event.Lon, event.Geo = departure.Coordinates()
+
+	return event, true
+}
+
+// UID identifies flight on its date, so calendars importing the event again update it instead of adding another one
+func UID(segment model.Segment) string {
+	flight := segment.FlightNumber
+	if flight == "" {
+		flight = "flight"
+	}
+
+	return strings.ToLower(fmt.Sprintf("%s-%s-%s%s@%s",
+		flight, strings.ReplaceAll(segment.Date, "-", ""), segment.Departure, segment.Arrival, UIDDomain))
+}
+
+// summary is flight number and cities, e.g. SQ944 Singapore → Denpasar
+func summary(segment model.Segment, departure, arrival *airport.Airport) string {
+	flight := segment.FlightNumber
+	if flight == "" {
+		flight = "Flight"
+	}
+
+	return fmt.Sprintf("%s %s → %s", flight, city(departure), city(arrival))
+}
+
+// location is airport name with IATA code and terminal, e.g. Singapore Changi Airport (SIN), Terminal 3
+func location(a *airport.Airport, terminal string) string {
+	name := a.IATA
+	if a.Name != "" {
+		name = fmt.Sprintf("%s (%s)", a.Name, a.IATA)
+	}
+
+	if terminal != "" {
+		name += ", Terminal " + terminal
+	}
+
+	return name
+}
+
+// description lists flight, reservation, both airports and passengers with their seats
+func description(itinerary model.Itinerary, index int, departure, arrival *airport.Airport) string {
+	var (
+		segment = itinerary.Segments[index]
+		lines   = make([]string, 0)
+	)
+
+	if segment.FlightNumber != "" {
+		flight := "Flight " + segment.FlightNumber
+		if len(segment.FlightNumber) > 2 {
+			if a, found := airline.Get(segment.FlightNumber[:2]); found {
+				flight += " " + a.Name
+			}
+		}
+		lines = append(lines, flight)
+	}
+
+	if segment.Reservation != "" {
+		lines = append(lines, "Reservation "+segment.Reservation)
+	}
+
+	departs := "Departs " + location(departure, segment.DepartureTerminal)
+	if segment.DepartureTime != "" {
+		departs = fmt.Sprintf("Departs %s %s", segment.DepartureTime, location(departure, segment.DepartureTerminal))
+	}
+	if segment.Gate != "" {
+		departs += ", Gate " + segment.Gate
+	}
+	lines = append(lines, departs)
+
+	arrives := "Arrives " + location(arrival, segment.ArrivalTerminal)
+	if segment.ArrivalTime != "" {
+		arrives = fmt.Sprintf("Arrives %s %s", segment.ArrivalTime, location(arrival, segment.ArrivalTerminal))
+	}
+	lines = append(lines, arrives)
+
+	for _, p := range itinerary.Passengers {
+		passenger := "Passenger " + p.Name
+		for _, s := range p.Segments {
+			if s.Segment != index {
+				continue
+			}
+			if s.Seat != "" {
+				passenger += ", Seat " + s.Seat
+			}
+			if s.Cabin != "" {
+				passenger += ", " + s.Cabin
+			}
+		}
+		lines = append(lines, passenger)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func city(a *airport.Airport) string {
+	if a.City != "" {
+		return a.City
+	}
+
+	return a.IATA
+}
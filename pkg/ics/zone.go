@@ -0,0 +1,105 @@
+package ics
+
+import (
+	"fmt"
+	"time"
+)
+
+// zone is a VTIMEZONE of location for years from first to last. Transitions are read from the time zone
+// database and written as they are, since rules of many zones changed over time.
+type zone struct {
+	location    *time.Location
+	first, last int
+}
+
+// encode writes offset in force at start of first year and every transition until end of last year
+func (z zone) encode(l *lines) {
+	var (
+		from = time.Date(z.first, time.January, 1, 0, 0, 0, 0, time.UTC)
+		to   = time.Date(z.last+1, time.January, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	l.add("BEGIN:VTIMEZONE")
+	l.add("TZID:" + z.location.String())
+
+	start := from.In(z.location)
+	_, offset := start.Zone()
+	observance(l, start, offset)
+
+	for _, t := range transitions(z.location, from, to) {
+		observance(l, t.In(z.location), offset)
+		_, offset = t.In(z.location).Zone()
+	}
+
+	l.add("END:VTIMEZONE")
+}
+
+// observance writes STANDARD or DAYLIGHT component starting at onset, onset is written in local time
+// of the offset before it
+func observance(l *lines, onset time.Time, before int) {
+	name, after := onset.Zone()
+
+	component := "STANDARD"
+	if onset.IsDST() {
+		component = "DAYLIGHT"
+	}
+
+	l.add("BEGIN:" + component)
+	l.add("DTSTART:" + onset.In(time.FixedZone("", before)).Format(localLayout))
+	l.add("TZOFFSETFROM:" + utcOffset(before))
+	l.add("TZOFFSETTO:" + utcOffset(after))
+	l.add("TZNAME:" + name)
+	l.add("END:" + component)
+}
+
+// transitions returns instants offset of location changes between from and to
+func transitions(location *time.Location, from, to time.Time) []time.Time {
+	var (
+		result    = make([]time.Time, 0)
+		_, offset = from.In(location).Zone()
+	)
+
+	// zones change offset at most once a day
+	for day := from; day.Before(to); day = day.Add(24 * time.Hour) {
+		next := day.Add(24 * time.Hour)
+		if _, o := next.In(location).Zone(); o == offset {
+			continue
+		}
+
+		before, after := day, next
+		for after.Sub(before) > time.Minute {
+			middle := before.Add(after.Sub(before) / 2)
+			if _, o := middle.In(location).Zone(); o == offset {
+				before = middle
+			} else {
+				after = middle
+			}
+		}
+
+		// transitions happen on whole minutes
+		transition := after.Truncate(time.Minute)
+		if _, o := transition.In(location).Zone(); o == offset {
+			transition = transition.Add(time.Minute)
+		}
+
+		result = append(result, transition)
+		_, offset = next.In(location).Zone()
+	}
+
+	return result
+}
+
+// utcOffset formats offset in seconds as +hhmm, seconds are only written if there are any
+func utcOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+
+	h, m, s := offset/3600, offset%3600/60, offset%60
+	if s != 0 {
+		return fmt.Sprintf("%s%02d%02d%02d", sign, h, m, s)
+	}
+
+	return fmt.Sprintf("%s%02d%02d", sign, h, m)
+}
@@ -0,0 +1,180 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/webhook"
+
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+)
+
+// callbacksKey is sorted set of jobs whose callbacks are due, scored by time of next attempt
+const callbacksKey = keyPrefix + "callbacks"
+
+func deliveriesKey(id string) string {
+	return keyPrefix + id + ":deliveries"
+}
+
+// Callback is body of callbacks of finished jobs, result is the one GET /jobs/:id returns
+type Callback struct {
+	Event  string          `json:"event"`
+	Job    Job             `json:"job"`
+	Result *model.Response `json:"result,omitempty"`
+}
+
+// Deliveries returns attempts to post callback of job, oldest first
+func (s *Store) Deliveries(id string) ([]webhook.Delivery, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+
+	raw, err := s.client.LRange(deliveriesKey(id), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make([]webhook.Delivery, 0, len(raw))
+	for _, r := range raw {
+		var d webhook.Delivery
+		if err := json.Unmarshal([]byte(r), &d); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, nil
+}
+
+// notify schedules callback of finished job, if it has one
+func (s *Store) notify(job Job) error {
+	if job.Callback == "" {
+		return nil
+	}
+
+	return s.client.ZAdd(callbacksKey, redis.Z{Score: float64(time.Now().Unix()), Member: job.ID}).Err()
+}
+
+// record saves delivery with job
+func (s *Store) record(job Job, delivery webhook.Delivery) error {
+	raw, err := json.Marshal(delivery)
+	if err != nil {
+		return err
+	}
+
+	if err := s.client.RPush(deliveriesKey(job.ID), raw).Err(); err != nil {
+		return err
+	}
+
+	return s.client.Expire(deliveriesKey(job.ID), remaining(job)).Err()
+}
+
+// deliver posts callbacks until ctx is done, failed deliveries are retried after backoff
+func (s *Store) deliver(ctx context.Context, log *zap.Logger) {
+	for {
+		if err := s.deliverDue(log); err != nil {
+			log.Error("failed to deliver callbacks",
+				zap.Error(err),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(poll):
+		}
+	}
+}
+
+// deliverDue posts callbacks which are due
+func (s *Store) deliverDue(log *zap.Logger) error {
+	due, err := s.client.ZRangeByScore(callbacksKey, redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range due {
+		// only the process which removed the callback delivers it
+		removed, err := s.client.ZRem(callbacksKey, id).Result()
+		if err != nil {
+			return err
+		}
+		if removed == 1 {
+			s.post(id, log.With(zap.String("jobID", id)))
+		}
+	}
+
+	return nil
+}
+
+// post makes an attempt to post callback of job and records it
+func (s *Store) post(id string, log *zap.Logger) {
+	job, err := s.Get(id)
+	if err != nil {
+		log.Warn("job of callback not found",
+			zap.Error(err),
+		)
+		return
+	}
+
+	callback := Callback{Event: "job." + job.Status, Job: job}
+	if job.Status == StatusSucceeded {
+		result, err := s.Result(id)
+		if err != nil {
+			log.Error("failed to get result of job",
+				zap.Error(err),
+			)
+			return
+		}
+
+		read := result.Response()
+		callback.Result = &read
+	}
+
+	body, err := json.Marshal(callback)
+	if err != nil {
+		log.Error("failed to write callback",
+			zap.Error(err),
+		)
+		return
+	}
+
+	attempt := 1
+	if n, err := s.client.LLen(deliveriesKey(id)).Result(); err == nil {
+		attempt += int(n)
+	}
+
+	delivery := webhook.Send(job.Callback, callback.Event, attempt, body)
+	if !delivery.Succeeded && attempt < webhook.Config.MaxAttempts {
+		retry := time.Now().Add(webhook.Backoff(attempt)).UTC()
+		delivery.RetryAt = &retry
+
+		if err := s.client.ZAdd(callbacksKey, redis.Z{Score: float64(retry.Unix()), Member: id}).Err(); err != nil {
+			delivery.RetryAt = nil
+			log.Error("failed to schedule retry of callback",
+				zap.Error(err),
+			)
+		}
+	}
+
+	log.Info("callback delivered",
+		zap.String("url", job.Callback),
+		zap.Int("attempt", attempt),
+		zap.Int("statusCode", delivery.StatusCode),
+		zap.Bool("succeeded", delivery.Succeeded),
+		zap.String("error", delivery.Error),
+	)
+
+	if err := s.record(job, delivery); err != nil {
+		log.Error("failed to record delivery",
+			zap.Error(err),
+		)
+	}
+}
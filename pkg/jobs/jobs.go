@@ -0,0 +1,306 @@
+// Package jobs reads uploads in background, jobs, their uploads and results are kept in Redis until they expire
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/crypto"
+	"trikliq-airport-finder/pkg/export"
+	redisclient "trikliq-airport-finder/pkg/redis"
+
+	"github.com/go-redis/redis"
+)
+
+// statuses of job, succeeded, failed and cancelled jobs are finished
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// keys of Redis, keys of a job are its ID after the prefix
+const (
+	keyPrefix = "jobs:"
+	// delayedKey is sorted set of jobs waiting for retry, scored by time of retry
+	delayedKey = keyPrefix + "delayed"
+)
+
+// maxConflicts is how often a transaction is tried again when its job was changed by another client
+const maxConflicts = 10
+
+var (
+	// ErrNotFound is returned for jobs which never existed or expired
+	ErrNotFound = errors.New("job not found")
+	// ErrFinished is returned when finished job is cancelled
+	ErrFinished = errors.New("job is already finished")
+	// ErrNoResult is returned for results of jobs which did not succeed
+	ErrNoResult = errors.New("job has no result")
+)
+
+// Job is reading of uploaded files, its result is kept apart from it
+type Job struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	Files       []string   `json:"files"`
+	Documents   int        `json:"documents"`
+	Attempts    int        `json:"attempts"`
+	MaxAttempts int        `json:"maxAttempts"`
+	Worker      string     `json:"worker,omitempty"`
+	Callback    string     `json:"callback,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	StartedAt   *time.Time `json:"startedAt,omitempty"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	ExpiresAt   time.Time  `json:"expiresAt"`
+}
+
+// Finished reports if job will not change anymore
+func (j Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed || j.Status == StatusCancelled
+}
+
+// Store keeps jobs in Redis, every key of job expires with it
+type Store struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewStore returns store of jobs which expire after ttl
+func NewStore(client *redis.Client, ttl time.Duration) *Store {
+	return &Store{client: client, ttl: ttl}
+}
+
+//lint:ignore GLOBAL this is okay
+var (
+	store     *Store
+	storeOnce sync.Once
+)
+
+// Default returns store of the service Redis client with TTL of Config
+func Default() *Store {
+	storeOnce.Do(func() {
+		store = NewStore(redisclient.Client, Config.TTL)
+	})
+
+	return store
+}
+
+func jobKey(id string) string {
+	return keyPrefix + id
+}
+
+func filesKey(id string) string {
+	return keyPrefix + id + ":files"
+}
+
+func resultKey(id string) string {
+	return keyPrefix + id + ":result"
+}
+
+func cancelKey(id string) string {
+	return keyPrefix + id + ":cancel"
+}
+
+// Create saves job of files and queues it, callback is posted once the job succeeds or fails
+func (s *Store) Create(files []model.MultipartFile, callback string) (Job, error) {
+	id, err := crypto.UUID()
+	if err != nil {
+		return Job{}, err
+	}
+
+	now := time.Now().UTC()
+	job := Job{
+		ID:          id,
+		Status:      StatusQueued,
+		Files:       make([]string, 0, len(files)),
+		MaxAttempts: Config.MaxAttempts,
+		Callback:    callback,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+	for _, f := range files {
+		job.Files = append(job.Files, f.Filename)
+	}
+
+	raw, err := json.Marshal(files)
+	if err != nil {
+		return Job{}, err
+	}
+	if err := s.client.Set(filesKey(id), raw, s.ttl).Err(); err != nil {
+		return Job{}, err
+	}
+	if err := s.save(job); err != nil {
+		return Job{}, err
+	}
+
+	return job, s.enqueue(id)
+}
+
+// Get returns job by ID
+func (s *Store) Get(id string) (Job, error) {
+	raw, err := s.client.Get(jobKey(id)).Bytes()
+	if err == redis.Nil {
+		return Job{}, ErrNotFound
+	}
+	if err != nil {
+		return Job{}, err
+	}
+
+	var job Job
+	err = json.Unmarshal(raw, &job)
+	return job, err
+}
+
+// Result returns result of succeeded job
+func (s *Store) Result(id string) (export.Result, error) {
+	raw, err := s.client.Get(resultKey(id)).Bytes()
+	if err == redis.Nil {
+		return export.Result{}, ErrNoResult
+	}
+	if err != nil {
+		return export.Result{}, err
+	}
+
+	var result export.Result
+	err = json.Unmarshal(raw, &result)
+	return result, err
+}
+
+// Cancel cancels job, queued jobs are cancelled at once and running jobs before their next document
+func (s *Store) Cancel(id string) (Job, error) {
+	job, err := s.update(id, func(job *Job) error {
+		if job.Finished() {
+			return ErrFinished
+		}
+		if job.Status == StatusQueued {
+			finish(job, StatusCancelled, "")
+		}
+		return nil
+	})
+	if err != nil {
+		return job, err
+	}
+
+	// running jobs were not changed, their worker checks for the key
+	if job.Status == StatusRunning {
+		if err := s.client.Set(cancelKey(id), 1, remaining(job)).Err(); err != nil {
+			return Job{}, err
+		}
+	}
+
+	return job, nil
+}
+
+// cancelled reports if cancellation of job was asked for
+func (s *Store) cancelled(id string) bool {
+	n, err := s.client.Exists(cancelKey(id)).Result()
+	return err == nil && n > 0
+}
+
+func (s *Store) files(id string) ([]model.MultipartFile, error) {
+	raw, err := s.client.Get(filesKey(id)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var files []model.MultipartFile
+	err = json.Unmarshal(raw, &files)
+	return files, err
+}
+
+func (s *Store) saveResult(job Job, result export.Result) error {
+	raw, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(resultKey(job.ID), raw, remaining(job)).Err()
+}
+
+// save saves job until it expires, uploads of finished jobs are not needed anymore
+func (s *Store) save(job Job) error {
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		return queueSave(pipe, job, raw)
+	})
+	return err
+}
+
+// update changes job by fn and saves it in a transaction, fn runs again if job was changed meanwhile. Job is
+// returned as read when fn fails.
+func (s *Store) update(id string, fn func(job *Job) error) (Job, error) {
+	var job Job
+	change := func(tx *redis.Tx) error {
+		raw, err := tx.Get(jobKey(id)).Bytes()
+		if err == redis.Nil {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		job = Job{}
+		if err := json.Unmarshal(raw, &job); err != nil {
+			return err
+		}
+		if err := fn(&job); err != nil {
+			return err
+		}
+
+		if raw, err = json.Marshal(job); err != nil {
+			return err
+		}
+		_, err = tx.TxPipelined(func(pipe redis.Pipeliner) error {
+			return queueSave(pipe, job, raw)
+		})
+		return err
+	}
+
+	var err error
+	for attempt := 0; attempt < maxConflicts; attempt++ {
+		if err = s.client.Watch(change, jobKey(id)); err != redis.TxFailedErr {
+			break
+		}
+	}
+
+	return job, err
+}
+
+func queueSave(pipe redis.Pipeliner, job Job, raw []byte) error {
+	pipe.Set(jobKey(job.ID), raw, remaining(job))
+	if job.Finished() {
+		pipe.Del(filesKey(job.ID))
+	}
+
+	return nil
+}
+
+// finish sets final status of job
+func finish(job *Job, status, message string) {
+	now := time.Now().UTC()
+	job.Status = status
+	job.Error = message
+	job.FinishedAt = &now
+}
+
+// remaining returns time until job expires, keys without expiration would never expire
+func remaining(job Job) time.Duration {
+	if ttl := time.Until(job.ExpiresAt); ttl > time.Second {
+		return ttl
+	}
+
+	return time.Second
+}
@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// keys of queue, jobs are messages of a stream read by a consumer group of workers
+const (
+	streamKey = keyPrefix + "stream"
+	// deadKey is stream of jobs which failed every attempt
+	deadKey = keyPrefix + "dead"
+	group   = "workers"
+
+	// maxDead is about how many dead letters are kept
+	maxDead = 10000
+	// maxClaims taken over at once, the rest is taken over on next poll
+	maxClaims = 10
+	// pendingPage is how many pending messages are looked at per request
+	pendingPage = 100
+)
+
+func workerKey(consumer string) string {
+	return keyPrefix + "workers:" + consumer
+}
+
+// message of stream, it has to be acknowledged once the job is handled
+type message struct {
+	id  string
+	job string
+}
+
+// Heartbeat is what a worker reports of itself while it runs
+type Heartbeat struct {
+	Consumer  string    `json:"consumer"`
+	Host      string    `json:"host"`
+	PID       int       `json:"pid"`
+	Job       string    `json:"job,omitempty"`
+	Processed int       `json:"processed"`
+	Failed    int       `json:"failed"`
+	StartedAt time.Time `json:"startedAt"`
+	SeenAt    time.Time `json:"seenAt"`
+}
+
+// enqueue adds job to stream of workers
+func (s *Store) enqueue(id string) error {
+	return s.client.XAdd(&redis.XAddArgs{
+		Stream: streamKey,
+		Values: map[string]interface{}{"job": id},
+	}).Err()
+}
+
+// group creates consumer group of workers, groups which exist already are fine
+func (s *Store) group() error {
+	err := s.client.XGroupCreateMkStream(streamKey, group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+
+	return err
+}
+
+// receive returns next message for consumer, or none if nothing came within wait
+func (s *Store) receive(consumer string, wait time.Duration) ([]message, error) {
+	streams, err := s.client.XReadGroup(&redis.XReadGroupArgs{
+		Group:    group,
+		Consumer: consumer,
+		Streams:  []string{streamKey, ">"},
+		Count:    1,
+		Block:    wait,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]message, 0)
+	for _, stream := range streams {
+		messages = append(messages, messagesOf(stream.Messages)...)
+	}
+
+	return messages, nil
+}
+
+// claim takes over messages pending longer than idle whose consumers stopped their heartbeats. Pending messages
+// are paged through, messages of live workers do not hide those of stopped ones.
+func (s *Store) claim(consumer string, idle time.Duration) ([]message, error) {
+	ids := make([]string, 0)
+	for start := "-"; len(ids) < maxClaims; {
+		pending, err := s.client.XPendingExt(&redis.XPendingExtArgs{
+			Stream: streamKey,
+			Group:  group,
+			Start:  start,
+			End:    "+",
+			Count:  pendingPage,
+		}).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range pending {
+			if len(ids) == maxClaims {
+				break
+			}
+			if p.Idle < idle || p.Consumer == consumer || s.alive(p.Consumer) {
+				continue
+			}
+			ids = append(ids, p.Id)
+		}
+
+		if len(pending) < pendingPage {
+			break
+		}
+		if start, err = nextID(pending[len(pending)-1].Id); err != nil {
+			return nil, err
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	// messages claimed by another worker in the meantime are not returned
+	claimed, err := s.client.XClaim(&redis.XClaimArgs{
+		Stream:   streamKey,
+		Group:    group,
+		Consumer: consumer,
+		MinIdle:  idle,
+		Messages: ids,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	return messagesOf(claimed), nil
+}
+
+// ack acknowledges message and removes it from stream, handled messages are not needed anymore
+func (s *Store) ack(m message) error {
+	if err := s.client.XAck(streamKey, group, m.id).Err(); err != nil {
+		return err
+	}
+
+	return s.client.XDel(streamKey, m.id).Err()
+}
+
+// deadLetter adds job which failed every attempt to stream of dead letters
+func (s *Store) deadLetter(job Job, consumer string) error {
+	return s.client.XAdd(&redis.XAddArgs{
+		Stream:       deadKey,
+		MaxLenApprox: maxDead,
+		Values: map[string]interface{}{
+			"job":      job.ID,
+			"error":    job.Error,
+			"attempts": job.Attempts,
+			"consumer": consumer,
+		},
+	}).Err()
+}
+
+// beat saves heartbeat of worker until it is overdue
+func (s *Store) beat(h Heartbeat, ttl time.Duration) error {
+	raw, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(workerKey(h.Consumer), raw, ttl).Err()
+}
+
+// leave removes worker which stopped. Consumers with pending messages stay in group, removing them would drop
+// the messages, they are taken over by other workers instead.
+func (s *Store) leave(consumer string) error {
+	if err := s.client.Del(workerKey(consumer)).Err(); err != nil {
+		return err
+	}
+
+	pending, err := s.client.XPendingExt(&redis.XPendingExtArgs{
+		Stream:   streamKey,
+		Group:    group,
+		Start:    "-",
+		End:      "+",
+		Count:    1,
+		Consumer: consumer,
+	}).Result()
+	if err != nil || len(pending) > 0 {
+		return err
+	}
+
+	return s.client.XGroupDelConsumer(streamKey, group, consumer).Err()
+}
+
+// alive reports if consumer has a heartbeat, errors count as alive so that jobs are not run twice
+func (s *Store) alive(consumer string) bool {
+	n, err := s.client.Exists(workerKey(consumer)).Result()
+	return err != nil || n > 0
+}
+
+// Workers returns heartbeats of running workers by name
+func (s *Store) Workers() ([]Heartbeat, error) {
+	var (
+		heartbeats = make([]Heartbeat, 0)
+		cursor     uint64
+	)
+
+	for {
+		keys, next, err := s.client.Scan(cursor, workerKey("*"), 100).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, key := range keys {
+			raw, err := s.client.Get(key).Bytes()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			var h Heartbeat
+			if err := json.Unmarshal(raw, &h); err != nil {
+				return nil, err
+			}
+			heartbeats = append(heartbeats, h)
+		}
+
+		if cursor = next; cursor == 0 {
+			break
+		}
+	}
+
+	sort.Slice(heartbeats, func(i, j int) bool {
+		return heartbeats[i].Consumer < heartbeats[j].Consumer
+	})
+
+	return heartbeats, nil
+}
+
+// nextID returns the smallest ID of stream after id, ranges of XPENDING include their start
+func nextID(id string) (string, error) {
+	ms, seq, found := strings.Cut(id, "-")
+	if !found {
+		return "", fmt.Errorf("invalid ID of stream message %q", id)
+	}
+
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid ID of stream message %q: %w", id, err)
+	}
+
+	return ms + "-" + strconv.FormatUint(n+1, 10), nil
+}
+
+func messagesOf(raw []redis.XMessage) []message {
+	messages := make([]message, 0, len(raw))
+	for _, m := range raw {
+		job, _ := m.Values["job"].(string)
+		messages = append(messages, message{id: m.ID, job: job})
+	}
+
+	return messages
+}
@@ -0,0 +1,332 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+)
+
+// newStore returns store of a Redis in memory, its clock is set so that idle times of messages can be moved
+func newStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	server.SetTime(time.Now())
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		client.Close()
+	})
+
+	store := NewStore(client, time.Hour)
+	if err := store.group(); err != nil {
+		t.Fatal(err)
+	}
+
+	return store, server
+}
+
+// configure sets Config for a test and restores it after
+func configure(t *testing.T, attempts int, backoff time.Duration) {
+	t.Helper()
+
+	saved := Config
+	Config.MaxAttempts = attempts
+	Config.Backoff = backoff
+	t.Cleanup(func() {
+		Config = saved
+	})
+}
+
+// failing creates job whose uploads are gone, every attempt of it fails
+func failing(t *testing.T, store *Store) Job {
+	t.Helper()
+
+	job, err := store.Create(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.client.Del(filesKey(job.ID)).Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	return job
+}
+
+func get(t *testing.T, store *Store, id string) Job {
+	t.Helper()
+
+	job, err := store.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return job
+}
+
+func TestAck(t *testing.T) {
+	store, _ := newStore(t)
+
+	if err := store.enqueue("job"); err != nil {
+		t.Fatal(err)
+	}
+	messages, err := store.receive("worker", time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 || messages[0].job != "job" {
+		t.Fatalf("got messages %+v", messages)
+	}
+
+	if err := store.ack(messages[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := store.client.XPending(streamKey, group).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pending.Count != 0 {
+		t.Errorf("got %d pending messages after ack", pending.Count)
+	}
+	if n := store.client.XLen(streamKey).Val(); n != 0 {
+		t.Errorf("got %d messages in stream after ack, want them deleted", n)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	configure(t, 3, time.Minute)
+	store, _ := newStore(t)
+	job := failing(t, store)
+
+	worker := NewWorker(store, "worker", zap.NewNop())
+	before := time.Now()
+	if status := worker.run(job.ID); status != StatusQueued {
+		t.Fatalf("got status %q, want %q", status, StatusQueued)
+	}
+
+	job = get(t, store, job.ID)
+	if job.Attempts != 1 || job.Error == "" {
+		t.Errorf("got %d attempts and error %q", job.Attempts, job.Error)
+	}
+
+	score, err := store.client.ZScore(delayedKey, job.ID).Result()
+	if err != nil {
+		t.Fatalf("job is not waiting for retry: %v", err)
+	}
+	if retry := time.Unix(int64(score), 0); retry.Before(before.Add(time.Minute-time.Second)) || retry.After(time.Now().Add(time.Minute)) {
+		t.Errorf("got retry at %v, want a minute after %v", retry, before)
+	}
+
+	// retries are queued once their backoff has passed
+	if err := worker.promote(); err != nil {
+		t.Fatal(err)
+	}
+	if n := store.client.XLen(streamKey).Val(); n != 1 {
+		t.Fatalf("got %d messages before backoff passed, want only the first", n)
+	}
+
+	store.client.ZAdd(delayedKey, redis.Z{Score: float64(time.Now().Unix() - 1), Member: job.ID})
+	if err := worker.promote(); err != nil {
+		t.Fatal(err)
+	}
+	if n := store.client.XLen(streamKey).Val(); n != 2 {
+		t.Errorf("got %d messages after backoff passed, want retry queued", n)
+	}
+	if n := store.client.ZCard(delayedKey).Val(); n != 0 {
+		t.Errorf("got %d delayed jobs after retry was queued", n)
+	}
+
+	// backoff doubles with every attempt
+	if wait := backoff(3); wait != 4*time.Minute {
+		t.Errorf("got backoff %v of third attempt", wait)
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	configure(t, 2, time.Minute)
+	store, _ := newStore(t)
+	job := failing(t, store)
+
+	worker := NewWorker(store, "worker", zap.NewNop())
+	if status := worker.run(job.ID); status != StatusQueued {
+		t.Fatalf("got status %q of first attempt", status)
+	}
+	if status := worker.run(job.ID); status != StatusFailed {
+		t.Fatalf("got status %q of last attempt, want %q", status, StatusFailed)
+	}
+
+	job = get(t, store, job.ID)
+	if job.Status != StatusFailed || job.Attempts != 2 || job.FinishedAt == nil {
+		t.Errorf("got job %+v", job)
+	}
+
+	dead, err := store.client.XRange(deadKey, "-", "+").Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || dead[0].Values["job"] != job.ID || dead[0].Values["consumer"] != "worker" {
+		t.Fatalf("got dead letters %+v", dead)
+	}
+
+	// finished jobs are not run again
+	if status := worker.run(job.ID); status != StatusFailed {
+		t.Errorf("got status %q of finished job", status)
+	}
+	if n := store.client.XLen(deadKey).Val(); n != 1 {
+		t.Errorf("got %d dead letters, want one", n)
+	}
+}
+
+func TestDeadLetterStoppedWorker(t *testing.T) {
+	configure(t, 2, time.Minute)
+	store, _ := newStore(t)
+	job := failing(t, store)
+
+	// the worker running the last attempt stopped without finishing it
+	if _, err := store.update(job.ID, func(job *Job) error {
+		job.Status = StatusRunning
+		job.Attempts = 2
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := NewWorker(store, "worker", zap.NewNop()).run(job.ID); status != StatusFailed {
+		t.Fatalf("got status %q, want %q", status, StatusFailed)
+	}
+	if job = get(t, store, job.ID); job.Attempts != 2 || job.Error == "" {
+		t.Errorf("got %d attempts and error %q", job.Attempts, job.Error)
+	}
+	if n := store.client.XLen(deadKey).Val(); n != 1 {
+		t.Errorf("got %d dead letters, want one", n)
+	}
+}
+
+func TestClaim(t *testing.T) {
+	store, server := newStore(t)
+	idle := 15 * time.Second
+
+	// messages of a live worker fill more than a page of pending messages before those of the stopped one
+	for i := 0; i < pendingPage+5; i++ {
+		if err := store.enqueue(fmt.Sprintf("live-%d", i)); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.receive("live", time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.beat(Heartbeat{Consumer: "live"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.enqueue("stopped"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.beat(Heartbeat{Consumer: "stopped"}, idle/overdue); err != nil {
+		t.Fatal(err)
+	}
+	received, err := store.receive("stopped", time.Millisecond)
+	if err != nil || len(received) != 1 {
+		t.Fatalf("got %+v, %v", received, err)
+	}
+
+	// messages are not taken over before they are idle long enough
+	claimed, err := store.claim("worker", idle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 0 {
+		t.Fatalf("claimed %+v before messages were idle", claimed)
+	}
+
+	// heartbeat of the stopped worker expires, the live one keeps beating
+	server.SetTime(time.Now().Add(2 * idle))
+	server.FastForward(idle)
+
+	claimed, err = store.claim("worker", idle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(claimed) != 1 || claimed[0].job != "stopped" || claimed[0].id != received[0].id {
+		t.Fatalf("got claimed %+v, want message of stopped worker", claimed)
+	}
+
+	pending, err := store.client.XPendingExt(&redis.XPendingExtArgs{
+		Stream:   streamKey,
+		Group:    group,
+		Start:    "-",
+		End:      "+",
+		Count:    10,
+		Consumer: "worker",
+	}).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Id != received[0].id {
+		t.Errorf("got pending messages %+v of worker", pending)
+	}
+}
+
+func TestNextID(t *testing.T) {
+	if id, err := nextID("1526569495631-9"); err != nil || id != "1526569495631-10" {
+		t.Errorf("got %q, %v", id, err)
+	}
+	if _, err := nextID("1526569495631"); err == nil {
+		t.Error("got no error of ID without sequence")
+	}
+}
+
+func TestCancel(t *testing.T) {
+	store, _ := newStore(t)
+
+	queued, err := store.Create(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := store.Cancel(queued.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != StatusCancelled || get(t, store, queued.ID).Status != StatusCancelled {
+		t.Errorf("got status %q of cancelled queued job", job.Status)
+	}
+	if store.client.Exists(filesKey(queued.ID)).Val() != 0 {
+		t.Error("uploads of cancelled job were kept")
+	}
+
+	// cancelled jobs are not started by workers which read them before
+	if status := NewWorker(store, "worker", zap.NewNop()).run(queued.ID); status != StatusCancelled {
+		t.Errorf("got status %q of cancelled job run by worker", status)
+	}
+	if _, err := store.Cancel(queued.ID); !errors.Is(err, ErrFinished) {
+		t.Errorf("got %v, want %v", err, ErrFinished)
+	}
+
+	// running jobs are cancelled by their worker
+	running, err := store.Create(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.update(running.ID, func(job *Job) error {
+		job.Status = StatusRunning
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if job, err = store.Cancel(running.ID); err != nil || job.Status != StatusRunning {
+		t.Fatalf("got %q, %v", job.Status, err)
+	}
+	if !store.cancelled(running.ID) {
+		t.Error("cancellation of running job was not asked for")
+	}
+
+	if _, err := store.Cancel("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("got %v, want %v", err, ErrNotFound)
+	}
+}
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+type JobsConfig struct {
+	// Workers reading jobs in a worker process
+	Workers int
+	// Embedded runs workers in the server process too, API nodes only queue jobs otherwise
+	Embedded bool
+	// Heartbeat is interval of heartbeats of workers, jobs of workers without heartbeat are taken over
+	Heartbeat time.Duration
+	// TTL of jobs, their uploads and results
+	TTL time.Duration
+	// MaxAttempts of a job before it fails
+	MaxAttempts int
+	// Backoff before the first retry, it doubles with every attempt
+	Backoff time.Duration
+}
+
+var Config JobsConfig
+
+func init() {
+
+	Config = JobsConfig{
+		Workers:     2,
+		TTL:         24 * time.Hour,
+		MaxAttempts: 3,
+		Backoff:     5 * time.Second,
+		Heartbeat:   5 * time.Second,
+	}
+
+	if workers, err := strconv.Atoi(os.Getenv("JOBS_WORKERS")); err == nil && workers >= 0 {
+		Config.Workers = workers
+	}
+	if embedded, err := strconv.ParseBool(os.Getenv("JOBS_EMBEDDED_WORKERS")); err == nil {
+		Config.Embedded = embedded
+	}
+	if ttl, err := time.ParseDuration(os.Getenv("JOBS_TTL")); err == nil && ttl > 0 {
+		Config.TTL = ttl
+	}
+	if attempts, err := strconv.Atoi(os.Getenv("JOBS_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		Config.MaxAttempts = attempts
+	}
+	if backoff, err := time.ParseDuration(os.Getenv("JOBS_BACKOFF")); err == nil && backoff > 0 {
+		Config.Backoff = backoff
+	}
+	if heartbeat, err := time.ParseDuration(os.Getenv("JOBS_HEARTBEAT")); err == nil && heartbeat > 0 {
+		Config.Heartbeat = heartbeat
+	}
+}
@@ -0,0 +1,427 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trikliq-airport-finder/pkg/export"
+	"trikliq-airport-finder/pkg/parse"
+	"trikliq-airport-finder/pkg/pool"
+
+	"github.com/go-redis/redis"
+	"go.uber.org/zap"
+)
+
+const (
+	// poll is how long stream is waited on before retried and abandoned jobs are looked at again
+	poll = time.Second
+	// maxBackoff between attempts of a job
+	maxBackoff = 10 * time.Minute
+	// overdue heartbeats, jobs of workers without heartbeat for that many intervals are taken over
+	overdue = 3
+)
+
+var errCancelled = errors.New("job was cancelled")
+
+// Run runs workers of Config until ctx is done, it returns once they finished their jobs
+func Run(ctx context.Context, log *zap.Logger) {
+	var (
+		wg      sync.WaitGroup
+		host, _ = os.Hostname()
+	)
+
+	log.Info("workers started",
+		zap.Int("workers", Config.Workers),
+		zap.Duration("heartbeat", Config.Heartbeat),
+	)
+
+	for i := 0; i < Config.Workers; i++ {
+		consumer := fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			NewWorker(Default(), consumer, log.With(zap.String("consumer", consumer))).Run(ctx)
+		}()
+	}
+
+	// callbacks are posted apart from jobs, slow receivers do not hold up workers
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		Default().deliver(ctx, log.With(zap.String("consumer", host+"-callbacks")))
+	}()
+
+	wg.Wait()
+
+	log.Info("workers stopped")
+}
+
+// Worker runs jobs of the stream one at a time as a consumer of the group of workers
+type Worker struct {
+	store    *Store
+	consumer string
+	log      *zap.Logger
+
+	mu        sync.Mutex
+	heartbeat Heartbeat
+}
+
+// NewWorker returns worker of jobs of store, consumer names it in the group and must be unique
+func NewWorker(store *Store, consumer string, log *zap.Logger) *Worker {
+	host, _ := os.Hostname()
+
+	return &Worker{
+		store:    store,
+		consumer: consumer,
+		log:      log,
+		heartbeat: Heartbeat{
+			Consumer:  consumer,
+			Host:      host,
+			PID:       os.Getpid(),
+			StartedAt: time.Now().UTC(),
+		},
+	}
+}
+
+// Run runs jobs until ctx is done, the job being run is finished first. Errors of Redis are logged and waited out.
+func (w *Worker) Run(ctx context.Context) {
+	beats, stop := context.WithCancel(context.Background())
+	defer stop()
+	go w.beat(beats)
+
+	defer func() {
+		if err := w.store.leave(w.consumer); err != nil {
+			w.log.Error("failed to leave group of workers",
+				zap.Error(err),
+			)
+		}
+	}()
+
+	grouped := false
+	for ctx.Err() == nil {
+		if !grouped {
+			if err := w.store.group(); err != nil {
+				w.wait(ctx, "failed to create group of workers", err)
+				continue
+			}
+			grouped = true
+		}
+
+		if err := w.promote(); err != nil {
+			w.log.Error("failed to queue retried jobs",
+				zap.Error(err),
+			)
+		}
+
+		// jobs of stopped workers go first, they have waited the longest
+		messages, err := w.store.claim(w.consumer, overdue*Config.Heartbeat)
+		if err == nil && len(messages) == 0 {
+			messages, err = w.store.receive(w.consumer, poll)
+		}
+		if err != nil {
+			grouped = !ungrouped(err)
+			w.wait(ctx, "failed to read jobs", err)
+			continue
+		}
+
+		for _, m := range messages {
+			w.handle(m)
+		}
+	}
+}
+
+// wait logs error of Redis and waits a poll, or until ctx is done
+func (w *Worker) wait(ctx context.Context, message string, err error) {
+	w.log.Error(message,
+		zap.Error(err),
+	)
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(poll):
+	}
+}
+
+// beat reports heartbeat every interval of Config until ctx is done
+func (w *Worker) beat(ctx context.Context) {
+	ticker := time.NewTicker(Config.Heartbeat)
+	defer ticker.Stop()
+
+	for {
+		w.mu.Lock()
+		w.heartbeat.SeenAt = time.Now().UTC()
+		heartbeat := w.heartbeat
+		w.mu.Unlock()
+
+		if err := w.store.beat(heartbeat, overdue*Config.Heartbeat); err != nil {
+			w.log.Error("failed to report heartbeat",
+				zap.Error(err),
+			)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// promote queues retried jobs whose backoff has passed
+func (w *Worker) promote() error {
+	due, err := w.store.client.ZRangeByScore(delayedKey, redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(time.Now().Unix(), 10),
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range due {
+		// only the worker which removed the job queues it
+		removed, err := w.store.client.ZRem(delayedKey, id).Result()
+		if err != nil {
+			return err
+		}
+		if removed == 1 {
+			if err := w.store.enqueue(id); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// handle runs job of message and acknowledges it, retries are queued as new messages
+func (w *Worker) handle(m message) {
+	w.mu.Lock()
+	w.heartbeat.Job = m.job
+	w.mu.Unlock()
+
+	status := w.run(m.job)
+
+	w.mu.Lock()
+	w.heartbeat.Job = ""
+	w.heartbeat.Processed++
+	if status == StatusFailed {
+		w.heartbeat.Failed++
+	}
+	w.mu.Unlock()
+
+	// messages which are not acknowledged are taken over once heartbeats of worker stop
+	if err := w.store.ack(m); err != nil {
+		w.log.Error("failed to acknowledge job",
+			zap.String("jobID", m.job),
+			zap.Error(err),
+		)
+	}
+}
+
+// run runs an attempt of job and returns its status. Failed attempts are retried after backoff, jobs without
+// attempts left are dead-lettered.
+func (w *Worker) run(id string) string {
+	log := w.log.With(zap.String("jobID", id))
+
+	// cancellation and attempts of workers which stopped while running the job count before it starts, the
+	// job is started only if nobody changed it since
+	job, err := w.store.update(id, func(job *Job) error {
+		if job.Finished() {
+			return ErrFinished
+		}
+		if w.store.cancelled(id) {
+			finish(job, StatusCancelled, "")
+			return nil
+		}
+		if job.Attempts >= job.MaxAttempts {
+			if job.Error == "" {
+				job.Error = "worker stopped while running the job"
+			}
+			finish(job, StatusFailed, job.Error)
+			return nil
+		}
+
+		now := time.Now().UTC()
+		job.Status = StatusRunning
+		job.Attempts++
+		job.Worker = w.consumer
+		job.StartedAt = &now
+		job.Error = ""
+		return nil
+	})
+	switch {
+	case errors.Is(err, ErrFinished):
+		return job.Status
+	case errors.Is(err, ErrNotFound):
+		log.Warn("queued job not found",
+			zap.Error(err),
+		)
+		return ""
+	case err != nil:
+		log.Error("failed to start job",
+			zap.Error(err),
+		)
+		return ""
+	case job.Status == StatusFailed:
+		w.deadLetter(job, log)
+		return job.Status
+	case job.Status == StatusCancelled:
+		return job.Status
+	}
+
+	log.Info("job started",
+		zap.Int("attempt", job.Attempts),
+		zap.Int("files", len(job.Files)),
+	)
+
+	result, err := w.read(job, log)
+	switch {
+	case errors.Is(err, errCancelled):
+		finish(&job, StatusCancelled, "")
+
+	case err != nil && job.Attempts < job.MaxAttempts:
+		retry := time.Now().Add(backoff(job.Attempts))
+		job.Status = StatusQueued
+		job.Error = err.Error()
+
+		log.Warn("job attempt failed",
+			zap.Int("attempt", job.Attempts),
+			zap.Time("retry", retry),
+			zap.Error(err),
+		)
+
+		if err := w.store.client.ZAdd(delayedKey, redis.Z{Score: float64(retry.Unix()), Member: id}).Err(); err != nil {
+			job.Error = err.Error()
+			w.fail(&job, log)
+			return job.Status
+		}
+
+	case err != nil:
+		job.Error = err.Error()
+		w.fail(&job, log)
+		return job.Status
+
+	default:
+		job.Documents = len(result.Documents)
+		if err := w.store.saveResult(job, result); err != nil {
+			job.Error = err.Error()
+			w.fail(&job, log)
+			return job.Status
+		}
+		finish(&job, StatusSucceeded, "")
+	}
+
+	w.save(job, log)
+	if job.Status == StatusSucceeded {
+		w.notify(job, log)
+	}
+
+	log.Info("job attempt finished",
+		zap.String("status", job.Status),
+		zap.Int("documents", job.Documents),
+	)
+
+	return job.Status
+}
+
+// fail fails job for good and adds it to dead letters
+func (w *Worker) fail(job *Job, log *zap.Logger) {
+	finish(job, StatusFailed, job.Error)
+	w.save(*job, log)
+	w.deadLetter(*job, log)
+}
+
+// deadLetter adds failed job to dead letters and tells its callback
+func (w *Worker) deadLetter(job Job, log *zap.Logger) {
+	log.Warn("job failed",
+		zap.Int("attempts", job.Attempts),
+		zap.String("error", job.Error),
+	)
+
+	if err := w.store.deadLetter(job, w.consumer); err != nil {
+		log.Error("failed to dead-letter job",
+			zap.Error(err),
+		)
+	}
+
+	w.notify(job, log)
+}
+
+func (w *Worker) notify(job Job, log *zap.Logger) {
+	if err := w.store.notify(job); err != nil {
+		log.Error("failed to schedule callback",
+			zap.Error(err),
+		)
+	}
+}
+
+// read reads documents of uploaded files, cancellation is checked before every file. Panics of parsers
+// fail the attempt rather than the worker.
+func (w *Worker) read(job Job, log *zap.Logger) (result export.Result, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("reading failed: %v", r)
+		}
+	}()
+
+	files, err := w.store.files(job.ID)
+	if err != nil {
+		return result, err
+	}
+
+	result = export.NewResult()
+	for _, file := range files {
+		if w.store.cancelled(job.ID) {
+			return result, errCancelled
+		}
+
+		// parsing is bounded by the pool shared with requests of embedded workers
+		var documents []parse.Document
+		if err := pool.Default().Do(context.Background(), func() {
+			documents = parse.Documents(file, log, parse.Options{Now: job.CreatedAt})
+		}); err != nil {
+			return result, err
+		}
+
+		for _, document := range documents {
+			result.Add(document.Name, document.Itinerary, document.Err)
+		}
+	}
+
+	return result, nil
+}
+
+func (w *Worker) save(job Job, log *zap.Logger) {
+	if err := w.store.save(job); err != nil {
+		log.Error("failed to save job",
+			zap.String("status", job.Status),
+			zap.Error(err),
+		)
+	}
+}
+
+// backoff returns wait before the next attempt, it doubles with every attempt
+func backoff(attempt int) time.Duration {
+	wait := Config.Backoff
+	for i := 1; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > maxBackoff {
+		return maxBackoff
+	}
+
+	return wait
+}
+
+// ungrouped reports if error is of Redis having no group of workers, e.g. after the stream was deleted
+func ungrouped(err error) bool {
+	return strings.HasPrefix(err.Error(), "NOGROUP")
+}
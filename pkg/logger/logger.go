@@ -1,6 +1,9 @@
 package logger
 
 import (
+	"os"
+	"strings"
+
 	"trikliq-airport-finder/pkg/logger/encoders"
 	"trikliq-airport-finder/pkg/logger/filters"
 
@@ -46,10 +49,29 @@ func Setup() (logger *zap.Logger, err error) {
 			},
 			EncodeName: zapcore.FullNameEncoder,
 		},
-		OutputPaths:      []string{"stdout"},
+		OutputPaths:      []string{outputPath()},
 		ErrorOutputPaths: nil,
 		InitialFields:    nil,
 	}
 
 	return config.Build()
 }
+
+// Output is where logs and debugging output go, subcommands such as read print their results on stdout
+// so they use stderr
+func Output() *os.File {
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		return os.Stderr
+	}
+
+	return os.Stdout
+}
+
+// outputPath names Output the way zap does
+func outputPath() string {
+	if Output() == os.Stderr {
+		return "stderr"
+	}
+
+	return "stdout"
+}
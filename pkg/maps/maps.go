@@ -0,0 +1,315 @@
+// Package maps draws routes as self-contained SVG maps, the world outline is drawn from data of the service
+// so nothing is fetched from tile services
+package maps
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/geo"
+	"trikliq-airport-finder/pkg/transform"
+)
+
+const (
+	MimeType = "image/svg+xml"
+
+	// width and height of equirectangular projection of the whole world
+	width  = 960.0
+	height = 480.0
+
+	// stepKm is length of straight pieces of arcs
+	stepKm   = 200
+	maxSteps = 64
+
+	// minSpan is the narrowest map in degrees of longitude, routes within a country still show its region
+	minSpan = 60.0
+	// padding around routes, as fraction of their extent
+	padding = 0.15
+
+	markerRadius = 3.0
+	fontSize     = 11.0
+)
+
+// style of map, strokes keep their width when maps are zoomed to routes
+const style = `.sea{fill:#dceefb}.land{fill:#f4f1ea;stroke:#c9c2b2;stroke-width:.5;vector-effect:non-scaling-stroke}` +
+	`.water{fill:#dceefb;stroke:#c9c2b2;stroke-width:.5;vector-effect:non-scaling-stroke}` +
+	`.route{fill:none;stroke:#d1495b;stroke-width:2;stroke-linecap:round;vector-effect:non-scaling-stroke}` +
+	`.airport{fill:#00798c;stroke:#fff;stroke-width:1;vector-effect:non-scaling-stroke}` +
+	`text{font-family:sans-serif;fill:#30343f}.note{fill:#6b6f7a}`
+
+// Marker is an airport drawn on map
+type Marker struct {
+	IATA     string
+	Name     string
+	Lat, Lon float64
+}
+
+// Arc is great-circle path of a route as latitude and longitude pairs, split at the antimeridian
+type Arc struct {
+	From, To string
+	Lines    [][][2]float64
+}
+
+// MissingError lists airports of map which can not be drawn, it wraps airport.ErrNoCoordinates
+type MissingError struct {
+	Codes []string
+}
+
+func (e *MissingError) Error() string {
+	return "no coordinates for " + strings.Join(e.Codes, ", ")
+}
+
+func (e *MissingError) Unwrap() error {
+	return airport.ErrNoCoordinates
+}
+
+// Map is a map of routes, airports without coordinates can not be drawn and are listed as missing
+type Map struct {
+	Title   string
+	Markers []Marker
+	Arcs    []Arc
+	Missing []string
+}
+
+// Complete returns MissingError if any airport of map has no coordinates, such a map would silently
+// leave out its routes
+func (m *Map) Complete() error {
+	if len(m.Missing) > 0 {
+		return &MissingError{Codes: m.Missing}
+	}
+
+	return nil
+}
+
+// New returns map without routes
+func New(title string) *Map {
+	return &Map{Title: title, Markers: make([]Marker, 0), Arcs: make([]Arc, 0), Missing: make([]string, 0)}
+}
+
+// Itineraries returns map of segments of itineraries, airports unknown to the registry are missing
+func Itineraries(title string, itineraries []model.Itinerary, registry *airport.Registry) *Map {
+	m := New(title)
+
+	for _, itinerary := range itineraries {
+		for _, s := range itinerary.Segments {
+			m.Route(lookup(s.Departure, registry), lookup(s.Arrival, registry))
+		}
+	}
+
+	return m
+}
+
+// Codes returns map of route through airports of codes in order, e.g. SIN DPS SIN
+func Codes(title string, codes []string, registry *airport.Registry) *Map {
+	m := New(title)
+
+	for i := 1; i < len(codes); i++ {
+		m.Route(lookup(codes[i-1], registry), lookup(codes[i], registry))
+	}
+	if len(codes) == 1 {
+		m.Airport(lookup(codes[0], registry))
+	}
+
+	return m
+}
+
+func lookup(code string, registry *airport.Registry) *airport.Airport {
+	if a, found := registry.IATA(code); found {
+		return a
+	}
+
+	return &airport.Airport{IATA: code}
+}
+
+// Airport adds marker of airport, ok is false if it has no coordinates
+func (m *Map) Airport(a *airport.Airport) (marker Marker, ok bool) {
+	lat, lon, ok := a.Coordinates()
+	if !ok {
+		if !transform.InSlice(a.IATA, m.Missing) {
+			m.Missing = append(m.Missing, a.IATA)
+		}
+		return
+	}
+
+	marker = Marker{IATA: a.IATA, Name: a.Name, Lat: lat, Lon: lon}
+	for _, existing := range m.Markers {
+		if existing.IATA == a.IATA {
+			return marker, true
+		}
+	}
+
+	m.Markers = append(m.Markers, marker)
+	return marker, true
+}
+
+// Route adds arc between airports, routes flown both ways are drawn once
+func (m *Map) Route(from, to *airport.Airport) {
+	departure, fromOk := m.Airport(from)
+	arrival, toOk := m.Airport(to)
+	if !fromOk || !toOk || departure.IATA == arrival.IATA {
+		return
+	}
+
+	for _, a := range m.Arcs {
+		if (a.From == departure.IATA && a.To == arrival.IATA) || (a.From == arrival.IATA && a.To == departure.IATA) {
+			return
+		}
+	}
+
+	steps := int(geo.Haversine(departure.Lat, departure.Lon, arrival.Lat, arrival.Lon) / stepKm)
+	if steps > maxSteps {
+		steps = maxSteps
+	}
+
+	m.Arcs = append(m.Arcs, Arc{
+		From:  departure.IATA,
+		To:    arrival.IATA,
+		Lines: geo.SplitAntimeridian(geo.GreatCircle(departure.Lat, departure.Lon, arrival.Lat, arrival.Lon, steps)),
+	})
+}
+
+// Encode writes map as SVG document, the map is zoomed to its routes unless they cross the antimeridian
+func (m *Map) Encode(w io.Writer) error {
+	var b strings.Builder
+
+	x, y, span, tall := m.viewBox()
+	zoom := span / width
+
+	b.WriteString(xml.Header)
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="%s %s %s %s" role="img" aria-label="%s">`+"\n",
+		number(width), number(height), number(x), number(y), number(span), number(tall), escape(m.Title))
+	fmt.Fprintf(&b, "<title>%s</title>\n<style>%s</style>\n", escape(m.Title), style)
+	fmt.Fprintf(&b, `<rect class="sea" x="0" y="0" width="%s" height="%s"/>`+"\n", number(width), number(height))
+
+	outline := Outline()
+	for _, group := range []struct {
+		class  string
+		shapes []Shape
+	}{{"land", outline.Land}, {"water", outline.Water}} {
+		fmt.Fprintf(&b, `<g class="%s">`+"\n", group.class)
+		for _, s := range group.shapes {
+			fmt.Fprintf(&b, `<path d="%s"/>`+"\n", polygon(s.Coordinates))
+		}
+		b.WriteString("</g>\n")
+	}
+
+	b.WriteString(`<g class="routes">` + "\n")
+	for _, a := range m.Arcs {
+		for _, line := range a.Lines {
+			fmt.Fprintf(&b, `<path class="route" d="%s"><title>%s-%s</title></path>`+"\n", polyline(line), a.From, a.To)
+		}
+	}
+	b.WriteString("</g>\n")
+
+	b.WriteString(`<g class="airports">` + "\n")
+	for _, marker := range m.Markers {
+		cx, cy := project(marker.Lat, marker.Lon)
+		fmt.Fprintf(&b, `<circle class="airport" cx="%s" cy="%s" r="%s"><title>%s</title></circle>`+"\n",
+			number(cx), number(cy), number(markerRadius*zoom), escape(strings.TrimSpace(marker.IATA+" "+marker.Name)))
+		fmt.Fprintf(&b, `<text x="%s" y="%s" font-size="%s">%s</text>`+"\n",
+			number(cx+markerRadius*zoom*1.5), number(cy-markerRadius*zoom), number(fontSize*zoom), escape(marker.IATA))
+	}
+	b.WriteString("</g>\n")
+
+	if len(m.Missing) > 0 {
+		fmt.Fprintf(&b, `<text class="note" x="%s" y="%s" font-size="%s">No coordinates for %s</text>`+"\n",
+			number(x+8*zoom), number(y+tall-8*zoom), number(fontSize*zoom), escape(strings.Join(m.Missing, ", ")))
+	}
+
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// viewBox returns part of the world around routes, with aspect of the whole map. Maps of routes crossing
+// the antimeridian and maps without routes show the whole world.
+func (m *Map) viewBox() (x, y, w, h float64) {
+	if len(m.Markers) == 0 {
+		return 0, 0, width, height
+	}
+
+	minX, minY, maxX, maxY := width, height, 0.0, 0.0
+	extend := func(lat, lon float64) {
+		px, py := project(lat, lon)
+		minX, maxX = math.Min(minX, px), math.Max(maxX, px)
+		minY, maxY = math.Min(minY, py), math.Max(maxY, py)
+	}
+
+	for _, marker := range m.Markers {
+		extend(marker.Lat, marker.Lon)
+	}
+	for _, a := range m.Arcs {
+		if len(a.Lines) > 1 {
+			return 0, 0, width, height
+		}
+		for _, p := range a.Lines[0] {
+			extend(p[0], p[1])
+		}
+	}
+
+	w = math.Max((maxX-minX)*(1+2*padding), minSpan/360*width)
+	h = math.Max((maxY-minY)*(1+2*padding), w*height/width)
+	w = math.Max(w, h*width/height)
+	if w >= width || h >= height {
+		return 0, 0, width, height
+	}
+
+	x = clamp((minX+maxX)/2-w/2, 0, width-w)
+	y = clamp((minY+maxY)/2-h/2, 0, height-h)
+	return
+}
+
+// project returns position of coordinates on equirectangular map
+func project(lat, lon float64) (x, y float64) {
+	return (lon + 180) / 360 * width, (90 - lat) / 180 * height
+}
+
+// polygon returns path data of closed shape of longitude and latitude pairs
+func polygon(coordinates [][2]float64) string {
+	points := make([][2]float64, 0, len(coordinates))
+	for _, c := range coordinates {
+		points = append(points, [2]float64{c[1], c[0]})
+	}
+
+	return polyline(points) + "Z"
+}
+
+// polyline returns path data of line through latitude and longitude pairs
+func polyline(points [][2]float64) string {
+	var b strings.Builder
+
+	for i, p := range points {
+		x, y := project(p[0], p[1])
+		if i == 0 {
+			b.WriteString("M")
+		} else {
+			b.WriteString("L")
+		}
+		b.WriteString(number(x) + " " + number(y))
+	}
+
+	return b.String()
+}
+
+// number formats coordinate of map with a decimal, which is enough for a map of this size
+func number(value float64) string {
+	return strconv.FormatFloat(math.Round(value*10)/10, 'f', -1, 64)
+}
+
+func escape(value string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(value))
+
+	return b.String()
+}
+
+func clamp(value, low, high float64) float64 {
+	return math.Max(low, math.Min(value, high))
+}
@@ -0,0 +1,58 @@
+package maps
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"trikliq-airport-finder/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// WorldPath is location of low resolution outline of land and inland seas, coordinates are longitude and latitude
+const WorldPath = "data/world.json"
+
+// Shape is a polygon of outline
+type Shape struct {
+	Name        string       `json:"name"`
+	Coordinates [][2]float64 `json:"coordinates"`
+}
+
+// World is outline of the world, water shapes are drawn over land
+type World struct {
+	Land  []Shape `json:"land"`
+	Water []Shape `json:"water"`
+}
+
+//lint:ignore GLOBAL this is okay
+var (
+	world     World
+	worldOnce sync.Once
+)
+
+func load() {
+	raw, err := os.ReadFile(WorldPath)
+	if err != nil {
+		logger.Log.Error("failed to read world outline",
+			zap.String("path", WorldPath),
+			zap.Error(err),
+		)
+		return
+	}
+
+	err = json.Unmarshal(raw, &world)
+	if err != nil {
+		logger.Log.Error("failed to parse world outline",
+			zap.String("path", WorldPath),
+			zap.Error(err),
+		)
+	}
+}
+
+// Outline returns outline of the world, maps without it still have their routes
+func Outline() World {
+	worldOnce.Do(load)
+
+	return world
+}
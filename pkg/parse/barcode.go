@@ -0,0 +1,341 @@
+package parse
+
+import (
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"strings"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/barcode"
+	"trikliq-airport-finder/pkg/bcbp"
+	"trikliq-airport-finder/pkg/pdf"
+	"trikliq-airport-finder/pkg/transform"
+
+	"go.uber.org/zap"
+)
+
+// Barcodes returns barcodes found in document, raw is either a PDF or an image
+func Barcodes(raw []byte, log *zap.Logger) []barcode.Result {
+	var (
+		mime   = http.DetectContentType(raw)
+		images []image.Image
+	)
+
+	switch {
+	case strings.HasPrefix(mime, "image/"):
+		img, err := pdf.DecodeImage(raw)
+		if err != nil {
+			log.Debug("failed to decode image",
+				zap.String("mimeType", mime),
+				zap.Error(err),
+			)
+			return nil
+		}
+		images = append(images, img)
+
+	case mime == "application/pdf":
+		images = pdf.Images(raw)
+	}
+
+	results := decodeImages(images)
+
+	// barcodes drawn as vector graphics are only visible on rendered pages
+	if len(results) == 0 && mime == "application/pdf" {
+		if pages, err := pdf.Render(raw); err == nil {
+			results = decodeImages(pages)
+		}
+	}
+
+	log.Debug("barcodes decoded",
+		zap.Int("images", len(images)),
+		zap.Any("barcodes", results),
+	)
+
+	return results
+}
+
+// barcodeCandidate reports if document with text may carry a boarding pass barcode,
+// scanned documents have no text at all
+func barcodeCandidate(txt string) bool {
+	return strings.TrimSpace(txt) == "" || strings.Contains(transform.Fold(txt), "boarding")
+}
+
+func decodeImages(images []image.Image) []barcode.Result {
+	results := make([]barcode.Result, 0)
+	for _, img := range images {
+		results = append(results, barcode.Decode(img)...)
+	}
+
+	return results
+}
+
+// boardingPasses decodes BCBP payloads of barcodes, the same boarding pass is often printed more than once
+func boardingPasses(results []barcode.Result) []*bcbp.BoardingPass {
+	var (
+		passes = make([]*bcbp.BoardingPass, 0)
+		seen   = make(map[string]bool)
+	)
+
+	for _, result := range results {
+		if seen[result.Text] {
+			continue
+		}
+		seen[result.Text] = true
+
+		passes = append(passes, bcbp.Find(result.Text)...)
+	}
+
+	return passes
+}
+
+// FromBarcodes maps BCBP barcodes onto itinerary, false if none of barcodes is a boarding pass.
+// Boarding passes carry no year, dates are resolved relative to reference.
+func FromBarcodes(results []barcode.Result, registry *airport.Registry, reference time.Time) (model.Itinerary, bool) {
+	passes := boardingPasses(results)
+	if len(passes) == 0 {
+		return model.Itinerary{}, false
+	}
+
+	itinerary := FromBoardingPasses(passes, registry, reference)
+	for i := range itinerary.Segments {
+		itinerary.Segments[i].Source = SourceBarcode
+	}
+
+	return itinerary, true
+}
+
+// reference returns date of the first segment found in text, or now if there is none
+func reference(itinerary model.Itinerary, now time.Time) time.Time {
+	for _, s := range itinerary.Segments {
+		if date, err := time.Parse("2006-01-02", s.Date); err == nil {
+			return date
+		}
+	}
+
+	return now
+}
+
+// Override replaces segments found in text with segments of a more reliable source, such as barcodes.
+// Details printed only in text, e.g. times and terminals, are kept and every disagreement is recorded as conflict.
+func Override(text, decoded model.Itinerary, registry *airport.Registry) model.Itinerary {
+	var (
+		segments  = append([]model.Segment(nil), text.Segments...)
+		matched   = make([]bool, len(text.Segments))
+		positions = make([]int, len(decoded.Segments))
+		conflicts = append([]model.Conflict(nil), text.Conflicts...)
+	)
+
+	for d, segment := range decoded.Segments {
+		t := matchSegment(text.Segments, matched, segment, d)
+		if t == -1 {
+			segments = append(segments, segment)
+			positions[d] = len(segments) - 1
+			continue
+		}
+
+		matched[t] = true
+		positions[d] = t
+		segment.Date = julianYear(segment, text.Segments[t])
+		conflicts = append(conflicts, segmentConflicts(t, segment, text.Segments[t])...)
+		segments[t] = mergeSegment(segment, text.Segments[t])
+	}
+
+	// conflicts of decoded itinerary refer to its own segment indexes too
+	for _, c := range decoded.Conflicts {
+		c.Segment = positions[c.Segment]
+		conflicts = append(conflicts, c)
+	}
+
+	codes := make([]string, 0, 2*len(segments))
+	for _, s := range segments {
+		codes = append(codes, s.Departure, s.Arrival)
+	}
+
+	result := Finalize(codes, registry)
+	result.Segments = segments
+	result.Metros = text.Metros
+	result.Conflicts = conflicts
+
+	result.Document = text.Document
+	if result.Document == nil || !result.Document.Travel {
+		result.Document = decoded.Document
+	}
+
+	// passengers of decoded itinerary refer to its own segment indexes
+	result.Passengers = make([]model.Passenger, 0, len(decoded.Passengers)+len(text.Passengers))
+	for _, p := range decoded.Passengers {
+		for i := range p.Segments {
+			p.Segments[i].Segment = positions[p.Segments[i].Segment]
+		}
+		result.Passengers = append(result.Passengers, p)
+	}
+
+	for _, p := range text.Passengers {
+		if p.Name == "" {
+			continue
+		}
+
+		merged := false
+		for i := range result.Passengers {
+			if samePassenger(result.Passengers[i].Name, p.Name) {
+				mergePassenger(&result.Passengers[i], p)
+				merged = true
+				break
+			}
+		}
+
+		if !merged {
+			result.Passengers = append(result.Passengers, p)
+		}
+	}
+
+	result.Receipt = mergeReceipt(decoded.Receipt, text.Receipt)
+
+	return result
+}
+
+// matchSegment finds segment in text describing the same flight, first by flight number, then by route,
+// then by position. Returns -1 if there is none.
+func matchSegment(segments []model.Segment, matched []bool, segment model.Segment, position int) int {
+	same := []func(s model.Segment) bool{
+		func(s model.Segment) bool {
+			return s.FlightNumber != "" && s.FlightNumber == segment.FlightNumber
+		},
+		func(s model.Segment) bool {
+			return s.Departure == segment.Departure && s.Arrival == segment.Arrival
+		},
+	}
+
+	for _, f := range same {
+		for i, s := range segments {
+			if !matched[i] && f(s) {
+				return i
+			}
+		}
+	}
+
+	if position < len(segments) && !matched[position] {
+		return position
+	}
+
+	return -1
+}
+
+// julianYear returns date of segment, or date of text if segment was read from boarding pass and text prints
+// the same day of another year. Boarding passes only carry day of year, so their year is just a guess.
+func julianYear(segment, text model.Segment) string {
+	if segment.Source != SourceBCBP && segment.Source != SourceBarcode {
+		return segment.Date
+	}
+
+	decoded, err := time.Parse("2006-01-02", segment.Date)
+	if err != nil {
+		return segment.Date
+	}
+
+	printed, err := time.Parse("2006-01-02", text.Date)
+	if err != nil || printed.YearDay() != decoded.YearDay() {
+		return segment.Date
+	}
+
+	return text.Date
+}
+
+// segmentConflicts lists fields text got wrong
+func segmentConflicts(index int, segment, text model.Segment) []model.Conflict {
+	conflicts := make([]model.Conflict, 0)
+
+	for _, field := range []struct{ name, value, rejected string }{
+		{"departure", segment.Departure, text.Departure},
+		{"arrival", segment.Arrival, text.Arrival},
+		{"flightNumber", segment.FlightNumber, text.FlightNumber},
+		{"date", segment.Date, text.Date},
+		{"departureTime", segment.DepartureTime, text.DepartureTime},
+		{"arrivalTime", segment.ArrivalTime, text.ArrivalTime},
+	} {
+		if field.value == "" || field.rejected == "" || field.value == field.rejected {
+			continue
+		}
+
+		conflicts = append(conflicts, model.Conflict{
+			Segment:        index,
+			Field:          field.name,
+			Value:          field.value,
+			Source:         segment.Source,
+			Rejected:       field.rejected,
+			RejectedSource: text.Source,
+		})
+	}
+
+	return conflicts
+}
+
+// mergeSegment fills details missing in segment from the same segment found in text
+func mergeSegment(segment, text model.Segment) model.Segment {
+	if segment.Date == "" {
+		segment.Date = text.Date
+	}
+
+	// times only belong to the segment if it is the same flight on the same day, boarding passes have no times
+	// but structured sources such as schema.org markup do
+	if segment.DepartureTime == "" && segment.ArrivalTime == "" &&
+		segment.Departure == text.Departure && segment.Arrival == text.Arrival && segment.Date == text.Date {
+		segment.DepartureTime = text.DepartureTime
+		segment.ArrivalTime = text.ArrivalTime
+		segment.BlockMinutes = text.BlockMinutes
+		segment.Overnight = text.Overnight
+	}
+
+	if segment.DepartureTerminal == "" {
+		segment.DepartureTerminal = text.DepartureTerminal
+	}
+	if segment.ArrivalTerminal == "" {
+		segment.ArrivalTerminal = text.ArrivalTerminal
+	}
+	if segment.Gate == "" {
+		segment.Gate = text.Gate
+	}
+	if segment.Reservation == "" {
+		segment.Reservation = text.Reservation
+	}
+
+	return segment
+}
+
+// mergePassenger adds name cut on boarding pass, frequent flyer numbers and details found only in text
+func mergePassenger(passenger *model.Passenger, text model.Passenger) {
+	if len(text.Name) > len(passenger.Name) {
+		passenger.Name = text.Name
+	}
+
+	if passenger.Title == "" {
+		passenger.Title = text.Title
+	}
+
+	for _, f := range text.FrequentFlyer {
+		if !hasFrequentFlyer(passenger, f.Number) {
+			passenger.FrequentFlyer = append(passenger.FrequentFlyer, f)
+		}
+	}
+
+	for _, t := range text.Segments {
+		details := passengerSegment(passenger, t.Segment)
+		if details.Cabin == "" {
+			details.Cabin = t.Cabin
+		}
+		if details.BookingClass == "" {
+			details.BookingClass = t.BookingClass
+		}
+		if details.Seat == "" {
+			details.Seat = t.Seat
+		}
+		if details.Baggage == "" {
+			details.Baggage = t.Baggage
+		}
+	}
+}
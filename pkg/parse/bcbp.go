@@ -0,0 +1,156 @@
+package parse
+
+import (
+	"strings"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airline"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/bcbp"
+	"trikliq-airport-finder/pkg/classify"
+)
+
+// FromBoardingPasses maps decoded boarding passes onto itinerary, passengers sharing a flight share its segment.
+// Boarding passes carry no year, dates are resolved relative to reference.
+func FromBoardingPasses(passes []*bcbp.BoardingPass, registry *airport.Registry, reference time.Time) model.Itinerary {
+	var (
+		codes      = make([]string, 0)
+		segments   = make([]model.Segment, 0)
+		passengers = make([]model.Passenger, 0)
+	)
+
+	for _, pass := range passes {
+		name, title, found := passengerName(pass.PassengerName, registry)
+		if !found {
+			name = pass.PassengerName
+		}
+
+		p := findPassenger(passengers, name)
+		if p == -1 {
+			passengers = append(passengers, model.Passenger{
+				Name:  name,
+				Title: title,
+				Type:  describedPassenger(pass.PassengerDescription),
+			})
+			p = len(passengers) - 1
+		}
+		passenger := &passengers[p]
+
+		for i, leg := range pass.Legs {
+			segment := model.Segment{
+				Departure:    leg.From,
+				Arrival:      leg.To,
+				FlightNumber: leg.Carrier + leg.FlightNumber,
+				Reservation:  leg.PNR,
+				Source:       SourceBCBP,
+				Confidence:   1,
+			}
+			if date, ok := pass.Date(i, reference); ok {
+				segment.Date = date.Format("2006-01-02")
+			}
+
+			s := findSegment(segments, segment)
+			if s == -1 {
+				segments = append(segments, segment)
+				codes = append(codes, leg.From, leg.To)
+				s = len(segments) - 1
+			}
+
+			details := passengerSegment(passenger, s)
+			details.Cabin = cabin(leg.Compartment)
+			details.BookingClass = leg.Compartment
+			details.Seat = leg.Seat
+			details.Baggage = allowance(leg.BaggageAllowance)
+
+			if leg.FrequentFlyerNumber != "" && !hasFrequentFlyer(passenger, leg.FrequentFlyerNumber) {
+				program := leg.FrequentFlyerAirline
+				if a, found := airline.Get(program); found {
+					program = a.Name
+				}
+
+				passenger.FrequentFlyer = append(passenger.FrequentFlyer, model.FrequentFlyer{
+					Program: program,
+					Number:  leg.FrequentFlyerNumber,
+				})
+			}
+		}
+	}
+
+	itinerary := Finalize(codes, registry)
+	itinerary.Segments = segments
+	itinerary.Passengers = passengers
+	itinerary.Document = &classify.Document{
+		Type:       classify.BoardingPass,
+		Confidence: 1,
+		Travel:     true,
+	}
+
+	return itinerary
+}
+
+// findSegment returns index of segment with the same flight, -1 if there is none
+func findSegment(segments []model.Segment, segment model.Segment) int {
+	for i, s := range segments {
+		if s.Departure == segment.Departure && s.Arrival == segment.Arrival &&
+			s.FlightNumber == segment.FlightNumber && s.Date == segment.Date {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func hasFrequentFlyer(passenger *model.Passenger, number string) bool {
+	for _, f := range passenger.FrequentFlyer {
+		if f.Number == number {
+			return true
+		}
+	}
+
+	return false
+}
+
+// describedPassenger maps BCBP passenger description onto passenger type
+func describedPassenger(description string) string {
+	switch description {
+	case "3", "7":
+		return PassengerChild
+	case "4":
+		return PassengerInfant
+	}
+
+	return PassengerAdult
+}
+
+// cabin guesses cabin from compartment code, airlines are free to assign them differently
+func cabin(compartment string) string {
+	switch compartment {
+	case "F", "A", "P":
+		return "First"
+	case "J", "C", "D", "I", "Z", "R":
+		return "Business"
+	case "W":
+		return "Premium Economy"
+	case "":
+		return ""
+	}
+
+	return "Economy"
+}
+
+// allowance normalizes BCBP free baggage allowance, e.g. 20K, 2PC or 40L
+func allowance(value string) string {
+	value = strings.ToLower(value)
+
+	switch {
+	case strings.HasSuffix(value, "pc"):
+		return strings.TrimLeft(value, "0")
+	case strings.HasSuffix(value, "k"):
+		return strings.TrimLeft(value, "0") + "g"
+	case strings.HasSuffix(value, "l"):
+		return strings.TrimLeft(strings.TrimSuffix(value, "l"), "0") + "lb"
+	}
+
+	return value
+}
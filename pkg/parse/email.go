@@ -0,0 +1,284 @@
+package parse
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/classify"
+	"trikliq-airport-finder/pkg/email"
+	"trikliq-airport-finder/pkg/html"
+	"trikliq-airport-finder/pkg/schemaorg"
+	"trikliq-airport-finder/pkg/trip"
+
+	"go.uber.org/zap"
+)
+
+// BodyPart is name of email body in provenance
+const BodyPart = "body"
+
+// emailPart is itinerary read from body or one attachment of email
+type emailPart struct {
+	name      string
+	itinerary model.Itinerary
+}
+
+// reliability of part is confidence of its most reliable segment
+func (p emailPart) reliability() float64 {
+	best := 0.0
+	for _, s := range p.itinerary.Segments {
+		if s.Confidence > best {
+			best = s.Confidence
+		}
+	}
+
+	return best
+}
+
+// ErrMailbox is returned by Email for mailboxes of several messages, Documents reads them one by one
+var ErrMailbox = errors.New("mailbox holds several messages, each is a document of its own")
+
+// Email reads itinerary from a single email, mailboxes of several messages are read by Documents
+func Email(raw []byte, log *zap.Logger, options Options) (model.Itinerary, error) {
+	messages, err := email.Read(raw)
+	if err != nil {
+		log.Warn("failed to read email",
+			zap.Error(err),
+		)
+		return model.Itinerary{}, err
+	}
+
+	if len(messages) != 1 {
+		return model.Itinerary{}, ErrMailbox
+	}
+
+	return Message(messages[0], log, options)
+}
+
+// HTML reads itinerary from HTML document the same way as from body of email, e.g. saved confirmation pages
+func HTML(raw []byte, log *zap.Logger, options Options) (model.Itinerary, error) {
+	return Message(&email.Message{
+		Bodies: []email.Part{{ID: "1", ContentType: "text/html", Content: raw}},
+	}, log, options)
+}
+
+// Message reads itinerary from body and attachments of email. Itineraries of parts are merged,
+// more reliable sources override less reliable ones and every value is traced back to part it was read from.
+func Message(message *email.Message, log *zap.Logger, options Options) (model.Itinerary, error) {
+	var (
+		registry = airport.Default()
+		parts    = make([]emailPart, 0, 1+len(message.Attachments))
+		document *classify.Document
+		last     error
+	)
+
+	add := func(name string, itinerary model.Itinerary, err error) {
+		if err != nil {
+			log.Debug("email part skipped",
+				zap.String("part", name),
+				zap.Error(err),
+			)
+
+			if !errors.Is(err, ErrNotTravel) {
+				last = err
+			}
+			if document == nil {
+				document = itinerary.Document
+			}
+			return
+		}
+
+		if len(itinerary.Segments) == 0 {
+			return
+		}
+
+		parts = append(parts, emailPart{name: name, itinerary: itinerary})
+	}
+
+	if body := messageBody(message); body != "" {
+		itinerary, err := ParseText(body, log, options)
+		add(BodyPart, itinerary, err)
+	}
+
+	// markup of body is more reliable than its text
+	if markup, found := FromReservations(messageReservations(message, log), registry); found {
+		add(BodyPart, markup, nil)
+	}
+
+	for _, attachment := range message.Attachments {
+		// declared content types of attachments are often just application/octet-stream
+		mime := sniff(attachment.Content)
+		if mime == "" {
+			continue
+		}
+
+		itinerary, err := File(model.MultipartFile{
+			Filename: attachment.Name(),
+			Size:     int64(len(attachment.Content)),
+			MimeType: mime,
+			Content:  attachment.Content,
+		}, log, options)
+		add(attachment.Name(), itinerary, err)
+	}
+
+	log.Debug("email read",
+		zap.String("subject", message.Subject),
+		zap.Int("bodies", len(message.Bodies)),
+		zap.Int("attachments", len(message.Attachments)),
+		zap.Int("parts", len(parts)),
+	)
+
+	if len(parts) == 0 {
+		if last != nil {
+			return model.Itinerary{}, last
+		}
+		if document == nil {
+			document = &classify.Document{Type: classify.Other, Confidence: 1}
+		}
+		return model.Itinerary{Document: document}, ErrNotTravel
+	}
+
+	finalized := mergeParts(parts, registry)
+	Enrich(&finalized, registry)
+	trip.Assemble(&finalized, registry)
+
+	return finalized, nil
+}
+
+// messageBody returns text of email, HTML bodies are preferred as plain text alternatives often lose tables
+func messageBody(message *email.Message) string {
+	var (
+		htmls  = make([]string, 0)
+		plains = make([]string, 0)
+	)
+
+	for _, b := range message.Bodies {
+		switch b.ContentType {
+		case "text/html":
+			if txt, err := html.Text(b.Content); err == nil && txt != "" {
+				htmls = append(htmls, txt)
+			}
+		default:
+			if txt := strings.TrimSpace(string(b.Content)); txt != "" {
+				plains = append(plains, txt)
+			}
+		}
+	}
+
+	if len(htmls) > 0 {
+		return strings.Join(htmls, "\n\n")
+	}
+
+	return strings.Join(plains, "\n\n")
+}
+
+// messageReservations returns schema.org flight reservations embedded in HTML bodies
+func messageReservations(message *email.Message, log *zap.Logger) []schemaorg.Reservation {
+	reservations := make([]schemaorg.Reservation, 0)
+
+	for _, b := range message.Bodies {
+		if b.ContentType != "text/html" {
+			continue
+		}
+
+		found, err := schemaorg.Find(b.Content)
+		if err != nil {
+			log.Debug("failed to read markup",
+				zap.String("part", b.Name()),
+				zap.Error(err),
+			)
+			continue
+		}
+		reservations = append(reservations, found...)
+	}
+
+	return reservations
+}
+
+// mergeParts overrides less reliable parts with more reliable ones, body loses to attachments of the same reliability
+func mergeParts(parts []emailPart, registry *airport.Registry) model.Itinerary {
+	sort.SliceStable(parts, func(i, j int) bool {
+		return parts[i].reliability() < parts[j].reliability()
+	})
+
+	merged := parts[0].itinerary
+	for _, p := range parts[1:] {
+		merged = Override(merged, p.itinerary, registry)
+	}
+
+	merged.Provenance = provenance(merged.Segments, parts)
+	return merged
+}
+
+// provenance finds for every value of segments the most reliable part which has the same value
+func provenance(segments []model.Segment, parts []emailPart) []model.Provenance {
+	result := make([]model.Provenance, 0)
+
+	for i, segment := range segments {
+		for _, field := range segmentFields(segment) {
+			if field.value == "" {
+				continue
+			}
+
+		search:
+			for p := len(parts) - 1; p >= 0; p-- {
+				for _, s := range parts[p].itinerary.Segments {
+					if !sameFlight(s, segment) || fieldValue(s, field.name) != field.value {
+						continue
+					}
+
+					result = append(result, model.Provenance{
+						Segment: i,
+						Field:   field.name,
+						Source:  s.Source,
+						Part:    parts[p].name,
+					})
+					break search
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+// sameFlight reports if segments describe the same flight, by flight number or by route
+func sameFlight(a, b model.Segment) bool {
+	if a.FlightNumber != "" && a.FlightNumber == b.FlightNumber {
+		return true
+	}
+
+	return a.Departure == b.Departure && a.Arrival == b.Arrival
+}
+
+type segmentField struct {
+	name, value string
+}
+
+// segmentFields lists values of segment which are read from documents
+func segmentFields(s model.Segment) []segmentField {
+	return []segmentField{
+		{"departure", s.Departure},
+		{"arrival", s.Arrival},
+		{"flightNumber", s.FlightNumber},
+		{"reservation", s.Reservation},
+		{"date", s.Date},
+		{"departureTime", s.DepartureTime},
+		{"arrivalTime", s.ArrivalTime},
+		{"departureTerminal", s.DepartureTerminal},
+		{"arrivalTerminal", s.ArrivalTerminal},
+		{"gate", s.Gate},
+	}
+}
+
+func fieldValue(s model.Segment, name string) string {
+	for _, f := range segmentFields(s) {
+		if f.name == name {
+			return f.value
+		}
+	}
+
+	return ""
+}
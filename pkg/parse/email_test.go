@@ -0,0 +1,62 @@
+package parse
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/email"
+	"trikliq-airport-finder/pkg/gds"
+)
+
+// agents paste PNR displays into HTML emails as preformatted text, with a plain text alternative
+const gdsEmail = "From: agent@example.com\r\n" +
+	"To: traveller@example.com\r\n" +
+	"Subject: Your itinerary 6GIY5Q\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"b\"\r\n" +
+	"\r\n" +
+	"--b\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"\r\n" +
+	"Please find your itinerary below.\r\n" +
+	"--b\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<html><body><p>Dear traveller,</p><p>please find your itinerary below.</p>\r\n" +
+	"<pre style=\"font-family: monospace\">\r\n" +
+	" 1.1DESMARAIS/LUC MR\r\n" +
+	"  1  SQ 938 Y 12MAR 2 SINDPS HK1  0930 1225  12MAR  E  SQ/6GIY5Q\r\n" +
+	"  2  SQ 947 Y 19MAR 2 DPSSIN HK1  1700 1935  19MAR  E  SQ/6GIY5Q\r\n" +
+	"</pre><p>Kind regards</p></body></html>\r\n" +
+	"--b--\r\n"
+
+func TestEmailPreformattedDisplay(t *testing.T) {
+	registry, err := airport.Load(filepath.Join("..", "..", airport.DataPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := email.Parse([]byte(gdsEmail))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := messageBody(message)
+	if !strings.Contains(body, "\n  1  SQ 938 Y 12MAR 2 SINDPS HK1  0930 1225  12MAR  E  SQ/6GIY5Q\n") {
+		t.Fatalf("lines and spacing of display were not kept:\n%s", body)
+	}
+	if lines := gds.Find(body); len(lines) != 2 {
+		t.Fatalf("got %d segments of display, want 2", len(lines))
+	}
+
+	itinerary, found := recognizeGDS(body, registry, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+	if !found || len(itinerary.Segments) != 2 {
+		t.Fatalf("got %+v", itinerary.Segments)
+	}
+	if s := itinerary.Segments[0]; s.Departure != "SIN" || s.Arrival != "DPS" || s.Date != "2024-03-12" || s.DepartureTime != "09:30" {
+		t.Errorf("got first segment %+v", s)
+	}
+}
@@ -0,0 +1,197 @@
+package parse
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/docx"
+	"trikliq-airport-finder/pkg/email"
+	"trikliq-airport-finder/pkg/pkpass"
+
+	"go.uber.org/zap"
+)
+
+// kinds of uploaded documents
+const (
+	KindPDF    = "pdf"
+	KindImage  = "image"
+	KindPkpass = "pkpass"
+	KindEmail  = "email"
+	KindHTML   = "html"
+	KindDocx   = "docx"
+)
+
+// MIME types of supported documents which http.DetectContentType does not tell apart
+const (
+	MimePDF    = "application/pdf"
+	MimeHTML   = "text/html"
+	MimePkpass = "application/vnd.apple.pkpass"
+	MimeDocx   = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	MimeEmail  = "message/rfc822"
+	MimeMbox   = "application/mbox"
+)
+
+// kinds of documents by their MIME types, images are matched by prefix
+var kinds = map[string]string{
+	MimePDF:    KindPDF,
+	MimeHTML:   KindHTML,
+	MimePkpass: KindPkpass,
+	MimeDocx:   KindDocx,
+	MimeEmail:  KindEmail,
+	MimeMbox:   KindEmail,
+}
+
+// extensions of documents whose content is ambiguous, e.g. plain text emails or zip archives
+var extensions = map[string]string{
+	".pdf":      MimePDF,
+	".html":     MimeHTML,
+	".htm":      MimeHTML,
+	".pkpass":   MimePkpass,
+	".pkpasses": MimePkpass,
+	".docx":     MimeDocx,
+	".eml":      MimeEmail,
+	".mbox":     MimeMbox,
+	".mbx":      MimeMbox,
+}
+
+// MimeType detects MIME type of document from its content, extension is only used when content is ambiguous
+func MimeType(filename string, raw []byte) string {
+	if mime := sniff(raw); mime != "" {
+		return mime
+	}
+
+	if mime, found := extensions[strings.ToLower(filepath.Ext(filename))]; found {
+		return mime
+	}
+
+	return baseType(http.DetectContentType(raw))
+}
+
+// sniff detects MIME type of supported document from its content only, empty string is returned for anything else
+func sniff(raw []byte) string {
+	mime := baseType(http.DetectContentType(raw))
+
+	switch {
+	case mime == MimePDF, mime == MimeHTML, strings.HasPrefix(mime, "image/"):
+		return mime
+	case mime == "application/zip" && pkpass.IsArchive(raw):
+		return MimePkpass
+	case mime == "application/zip" && docx.IsDocument(raw):
+		return MimeDocx
+	case mime == "text/plain" && email.IsMbox(raw):
+		return MimeMbox
+	case mime == "text/plain" && email.IsMessage(raw):
+		return MimeEmail
+	}
+
+	return ""
+}
+
+// baseType drops parameters of MIME type, such as charset
+func baseType(mime string) string {
+	if i := strings.IndexByte(mime, ';'); i != -1 {
+		mime = mime[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(mime))
+}
+
+// kindOf returns kind of document of MIME type, empty string for unsupported types
+func kindOf(mime string) string {
+	if strings.HasPrefix(mime, "image/") {
+		return KindImage
+	}
+
+	return kinds[mime]
+}
+
+// Kind detects kind of document, documents of unknown type are read as PDF
+func Kind(filename string, raw []byte) string {
+	if kind := kindOf(MimeType(filename, raw)); kind != "" {
+		return kind
+	}
+
+	return KindPDF
+}
+
+// fileKind returns kind of uploaded file, MIME type sniffed on upload is used if there is one
+func fileKind(file model.MultipartFile) string {
+	if kind := kindOf(file.MimeType); kind != "" {
+		return kind
+	}
+
+	return Kind(file.Filename, file.Content)
+}
+
+// File reads itinerary from uploaded file of any supported kind
+func File(file model.MultipartFile, log *zap.Logger, options Options) (model.Itinerary, error) {
+	kind := fileKind(file)
+
+	log.Debug("reading file",
+		zap.String("filename", file.Filename),
+		zap.String("mimeType", file.MimeType),
+		zap.String("kind", kind),
+	)
+
+	switch kind {
+	case KindPkpass:
+		return Pkpass(file.Content, log, options)
+	case KindEmail:
+		return Email(file.Content, log, options)
+	case KindHTML:
+		return HTML(file.Content, log, options)
+	case KindDocx:
+		return Docx(file.Content, log, options)
+	}
+
+	return Parse(file.Content, log, options)
+}
+
+// Docx reads itinerary from Word document, its text goes through the same extractors as text of PDFs
+func Docx(raw []byte, log *zap.Logger, options Options) (model.Itinerary, error) {
+	txt, err := docx.Text(raw)
+	if err != nil {
+		log.Error("failed to extract text",
+			zap.Error(err),
+		)
+		return model.Itinerary{}, err
+	}
+
+	return ParseText(txt, log, options)
+}
+
+// Document is itinerary read from uploaded file, or from one message of uploaded mailbox
+type Document struct {
+	Name      string
+	Itinerary model.Itinerary
+	Err       error
+}
+
+// Documents reads itinerary from uploaded file, mailboxes are read into one itinerary per message
+// named after the file and position of message, e.g. inbox.mbox#2. Stages are reported to Progress of options.
+func Documents(file model.MultipartFile, log *zap.Logger, options Options) []Document {
+	if fileKind(file) != KindEmail || !email.IsMbox(file.Content) {
+		itinerary, err := File(file, log, options)
+		return []Document{{Name: file.Filename, Itinerary: itinerary, Err: err}}
+	}
+
+	messages, err := email.Read(file.Content)
+	if err != nil {
+		return []Document{{Name: file.Filename, Err: err}}
+	}
+
+	documents := make([]Document, 0, len(messages))
+	for i, message := range messages {
+		itinerary, err := Message(message, log, options)
+		documents = append(documents, Document{
+			Name:      fmt.Sprintf("%s#%d", file.Filename, i+1),
+			Itinerary: itinerary,
+			Err:       err,
+		})
+	}
+
+	return documents
+}
@@ -1,25 +1,51 @@
 package parse
 
-import "trikliq-airport-finder/pkg/transform"
+import (
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+)
 
-func Finalize(finalCandidates []string, iata map[string]map[string]string) (result map[string]any) {
+func Finalize(finalCandidates []string, registry *airport.Registry) (result model.Itinerary) {
 
-	departing := make([]map[string]string, 0)
-	arriving := make([]map[string]string, 0)
+	departing := make([]*airport.Airport, 0)
+	arriving := make([]*airport.Airport, 0)
+	segments := make([]model.Segment, 0)
 
 	for i, candidate := range finalCandidates {
-		if i%2 == 0 && !transform.InSliceOfMap(departing, candidate) {
-			departing = append(departing, iata[candidate])
+		// ambiguous city codes make segments, but are not airports
+		found, isAirport := registry.IATA(candidate)
+
+		if isAirport && i%2 == 0 && !inAirports(departing, candidate) {
+			departing = append(departing, found)
+		}
+
+		if isAirport && i%2 != 0 && !inAirports(arriving, candidate) {
+			arriving = append(arriving, found)
 		}
 
-		if i%2 != 0 && !transform.InSliceOfMap(arriving, candidate) {
-			arriving = append(arriving, iata[candidate])
+		// every departure followed by an arrival makes a segment
+		if i%2 != 0 {
+			segments = append(segments, model.Segment{
+				Departure: finalCandidates[i-1],
+				Arrival:   candidate,
+			})
 		}
 	}
 
-	result = make(map[string]any, 0)
-	result["departing"] = departing
-	result["arriving"] = arriving
+	result.Departing = departing
+	result.Arriving = arriving
+	result.Segments = segments
 
 	return
 }
+
+// inAirports checks if there is an airport with code in slice
+func inAirports(airports []*airport.Airport, code string) bool {
+	for _, a := range airports {
+		if a != nil && a.IATA == code {
+			return true
+		}
+	}
+
+	return false
+}
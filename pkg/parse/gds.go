@@ -0,0 +1,174 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/classify"
+	"trikliq-airport-finder/pkg/gds"
+)
+
+const (
+	// gdsConfidence is confidence of segments read from PNR displays, displays are structured but pasted by hand
+	gdsConfidence = 0.8
+	// gdsGuessedConfidence is confidence of segments whose year is a guess, display has no date to follow
+	gdsGuessedConfidence = 0.5
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	// 1.CREIGHTON/JEREMY PHILIP MR  2.CREIGHTON/ANNA MRS, or 1.1CREIGHTON/JEREMY MR of Sabre
+	nameElementRegex = regexp.MustCompile(`(?m)(?:^|[ \t])\d{1,2}\.\d?([A-Z][A-Z'\-]*/[A-Z][A-Z'\- ]*[A-Z])`)
+)
+
+// recognizeGDS reads air segments of PNR displays, names are read from name lines of the same display
+func recognizeGDS(txt string, registry *airport.Registry, now time.Time) (model.Itinerary, bool) {
+	lines := gds.Find(txt)
+	if len(lines) == 0 {
+		return model.Itinerary{}, false
+	}
+
+	var (
+		codes    = make([]string, 0)
+		segments = make([]model.Segment, 0)
+		classes  = make([]string, 0)
+	)
+
+	reference, guessed := displayDate(txt, now)
+
+	for _, line := range lines {
+		if line.Cancelled() {
+			continue
+		}
+
+		departure, found := registry.IATA(line.From)
+		if !found {
+			continue
+		}
+		arrival, found := registry.IATA(line.To)
+		if !found {
+			continue
+		}
+
+		segment := model.Segment{
+			Departure:     line.From,
+			Arrival:       line.To,
+			FlightNumber:  line.Carrier + line.FlightNumber,
+			Reservation:   line.Locator,
+			DepartureTime: clock(line.Departure),
+			ArrivalTime:   clock(line.Arrival),
+			Source:        SourceGDS,
+			Confidence:    gdsConfidence,
+		}
+
+		if date, ok := line.Date(reference); ok {
+			segment.Date = date.Format("2006-01-02")
+			segment.BlockMinutes = blockMinutes(date, line.Departure, line.Arrival+line.DayChange*24*60, departure.Tz, arrival.Tz)
+
+			// without a date in display the next occurrence of day is taken, past trips end up a year later
+			if guessed && line.Year == 0 {
+				segment.YearGuessed = true
+				segment.Confidence = gdsGuessedConfidence
+			}
+		}
+
+		// displays are often pasted more than once
+		if findSegment(segments, segment) != -1 {
+			continue
+		}
+
+		segments = append(segments, segment)
+		classes = append(classes, line.Class)
+		codes = append(codes, line.From, line.To)
+	}
+
+	if len(segments) == 0 {
+		return model.Itinerary{}, false
+	}
+
+	itinerary := Finalize(codes, registry)
+	itinerary.Segments = segments
+
+	Passengers(displayNames(txt), &itinerary, registry)
+	for p := range itinerary.Passengers {
+		for s, class := range classes {
+			passengerSegment(&itinerary.Passengers[p], s).BookingClass = class
+		}
+	}
+
+	document := classify.Classify(txt)
+	if document.Type == classify.Other {
+		document.Type = classify.ETicket
+	}
+	document.Travel = true
+	itinerary.Document = &document
+
+	return itinerary, true
+}
+
+// displayNames puts name elements of display on their own lines, so office and agent codes,
+// e.g. AA/SU, are not taken for names. Text without name elements is returned as it is.
+func displayNames(txt string) string {
+	names := make([]string, 0)
+	for _, match := range nameElementRegex.FindAllStringSubmatch(txt, -1) {
+		names = append(names, match[1])
+	}
+
+	if len(names) == 0 {
+		return txt
+	}
+
+	return strings.Join(names, "\n")
+}
+
+// displayDate returns date PNR display was issued or printed, segments have no year and follow it.
+// Labeled dates of issue or booking are preferred over the earliest printed date, now is used if there is none
+// and guessed is true.
+func displayDate(txt string, now time.Time) (reference time.Time, guessed bool) {
+	var earliest, labeled time.Time
+
+	add := func(offset, y int, m time.Month, d int) {
+		date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		if date.Day() != d {
+			return
+		}
+
+		if labeled.IsZero() && bookkeeping(txt, offset) {
+			labeled = date
+		}
+		if earliest.IsZero() || date.Before(earliest) {
+			earliest = date
+		}
+	}
+
+	for _, match := range dayMonthYearRegex.FindAllStringSubmatchIndex(txt, -1) {
+		m, ok := month(txt[match[4]:match[5]])
+		if !ok {
+			continue
+		}
+		d, _ := strconv.Atoi(txt[match[2]:match[3]])
+		add(match[0], year(txt[match[6]:match[7]]), m, d)
+	}
+
+	for _, match := range isoDateRegex.FindAllStringSubmatchIndex(txt, -1) {
+		m, _ := strconv.Atoi(txt[match[4]:match[5]])
+		d, _ := strconv.Atoi(txt[match[6]:match[7]])
+		if m < 1 || m > 12 {
+			continue
+		}
+		add(match[0], year(txt[match[2]:match[3]]), time.Month(m), d)
+	}
+
+	switch {
+	case !labeled.IsZero():
+		return labeled, false
+	case !earliest.IsZero():
+		return earliest, false
+	}
+
+	return now, true
+}
@@ -0,0 +1,152 @@
+package parse
+
+import (
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airline"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/transform"
+)
+
+const (
+	MetroExplicitCode = "explicit code"
+	MetroTerminalName = "terminal or airport name"
+	MetroCarrierHub   = "carrier hub"
+	MetroAmbiguous    = "ambiguous"
+)
+
+// Disambiguate picks airport of every metropolitan area mentioned in text by its name or city code.
+// Member codes present in text win, followed by terminal and airport names, followed by hubs of carriers
+// flying the itinerary. If none of them points to a single airport, every member is returned as alternative.
+func Disambiguate(txt string, codes []string, registry *airport.Registry) []model.Metro {
+	var (
+		folded   = transform.Fold(txt)
+		carriers = airline.FlightNumbers(txt)
+		result   = make([]model.Metro, 0)
+	)
+
+	for _, metro := range registry.Metros() {
+		if !transform.InSlice(metro.Code, codes) && !metro.Mentioned(folded) {
+			continue
+		}
+
+		resolution := model.Metro{
+			Code: metro.Code,
+			Name: metro.Name,
+		}
+
+		picked, reason := explicitMembers(metro, codes), MetroExplicitCode
+
+		if len(picked) == 0 {
+			picked, reason = hintedMembers(metro, folded), MetroTerminalName
+		}
+
+		// carrier hubs narrow down several hinted airports, or pick one of all members
+		if len(picked) != 1 && reason != MetroExplicitCode {
+			hubs := hubMembers(metro, carriers)
+			if len(picked) > 1 {
+				hubs = intersect(hubs, picked)
+			}
+			if len(hubs) == 1 {
+				picked, reason = hubs, MetroCarrierHub
+			}
+		}
+
+		if len(picked) == 0 || (len(picked) > 1 && reason != MetroExplicitCode) {
+			if len(picked) == 0 {
+				picked = metro.Airports
+			}
+
+			resolution.Alternatives = registry.ByImportance(picked)
+			resolution.Reason = MetroAmbiguous
+			result = append(result, resolution)
+			continue
+		}
+
+		resolution.Airport = picked[0]
+		resolution.Alternatives = picked[1:]
+		resolution.Reason = reason
+		result = append(result, resolution)
+	}
+
+	return result
+}
+
+// Resolve replaces metropolitan area codes with resolved airports, ambiguous codes are kept
+func Resolve(codes []string, metros []model.Metro) []string {
+	resolved := make([]string, 0, len(codes))
+
+	for _, code := range codes {
+		for _, metro := range metros {
+			if metro.Code == code && metro.Airport != "" {
+				code = metro.Airport
+				break
+			}
+		}
+		resolved = append(resolved, code)
+	}
+
+	return resolved
+}
+
+// explicitMembers returns member airports whose code is in text, in order of appearance
+func explicitMembers(metro *airport.Metro, codes []string) []string {
+	picked := make([]string, 0)
+
+	for _, code := range codes {
+		if transform.InSlice(code, metro.Airports) && !transform.InSlice(code, picked) {
+			picked = append(picked, code)
+		}
+	}
+
+	return picked
+}
+
+// hintedMembers returns member airports whose terminal or airport name is in text
+func hintedMembers(metro *airport.Metro, folded string) []string {
+	picked := make([]string, 0)
+
+	for _, member := range metro.Airports {
+		for _, hint := range metro.Hints[member] {
+			if strings.Contains(folded, hint) {
+				picked = append(picked, member)
+				break
+			}
+		}
+	}
+
+	return picked
+}
+
+// hubMembers returns member airports which are hubs of carriers found in text
+func hubMembers(metro *airport.Metro, carriers []airline.FlightNumber) []string {
+	picked := make([]string, 0)
+
+	for _, carrier := range carriers {
+		a, found := airline.Get(carrier.Carrier)
+		if !found {
+			continue
+		}
+
+		for _, hub := range a.Hubs {
+			if transform.InSlice(hub, metro.Airports) && !transform.InSlice(hub, picked) {
+				picked = append(picked, hub)
+			}
+		}
+	}
+
+	return picked
+}
+
+// intersect keeps codes which are in both slices, in order of the first one
+func intersect(a, b []string) []string {
+	both := make([]string, 0)
+	for _, code := range a {
+		if transform.InSlice(code, b) {
+			both = append(both, code)
+		}
+	}
+
+	return both
+}
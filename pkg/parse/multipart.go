@@ -3,7 +3,10 @@ package parse
 import (
 	"io"
 	"mime/multipart"
+	"sort"
 	"trikliq-airport-finder/internal/model"
+
+	"go.uber.org/zap"
 )
 
 func ReadMultipartFiles(files []*multipart.FileHeader) ([]model.MultipartFile, error) {
@@ -26,6 +29,7 @@ func ReadMultipartFiles(files []*multipart.FileHeader) ([]model.MultipartFile, e
 			Filename: file.Filename,
 			Size:     file.Size,
 			Header:   file.Header,
+			MimeType: MimeType(file.Filename, raw),
 			Content:  raw,
 		}
 
@@ -34,3 +38,28 @@ func ReadMultipartFiles(files []*multipart.FileHeader) ([]model.MultipartFile, e
 
 	return rawFiles, nil
 }
+
+// FormFiles reads files of every field of form, fields whose files can not be read are skipped. Files come
+// in order of their fields by name, and in order of upload within a field.
+func FormFiles(form *multipart.Form, log *zap.Logger) []model.MultipartFile {
+	files := make([]model.MultipartFile, 0)
+
+	fields := make([]string, 0, len(form.File))
+	for field := range form.File {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		rawFiles, err := ReadMultipartFiles(form.File[field])
+		if err != nil {
+			log.Error("error while reading multipart file(s)",
+				zap.Error(err),
+			)
+			continue
+		}
+		files = append(files, rawFiles...)
+	}
+
+	return files
+}
@@ -2,23 +2,120 @@ package parse
 
 import (
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io/ioutil"
-	"os"
-	"regexp"
 	"strings"
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/classify"
 	"trikliq-airport-finder/pkg/pdf"
 	"trikliq-airport-finder/pkg/transform"
+	"trikliq-airport-finder/pkg/trip"
 	"unicode"
 
 	"go.uber.org/zap"
 )
 
-func Parse(raw []byte, log *zap.Logger) (finalized map[string]any) {
+// ErrNotTravel is returned for documents which are not travel documents at all
+var ErrNotTravel = errors.New("document is not a travel document")
+
+// extractors which run on top of airports and segments
+type extractors struct {
+	schedule   bool
+	passengers bool
+	receipt    bool
+}
+
+// routes tells which extractors are worth running for a document type,
+// e.g. receipts print payment times which are not times of flights
+var routes = map[string]extractors{
+	classify.ETicket:        {schedule: true, passengers: true, receipt: true},
+	classify.BoardingPass:   {schedule: true, passengers: true},
+	classify.ScheduleChange: {schedule: true},
+	classify.Cancellation:   {schedule: true},
+	classify.Receipt:        {passengers: true, receipt: true},
+}
+
+// Parse reads itinerary from document, boarding pass barcodes override whatever text heuristics find
+func Parse(raw []byte, log *zap.Logger, options Options) (finalized model.Itinerary, err error) {
+
+	registry := airport.Default()
+
+	txt, err := pdf.PdfToTxt(raw)
+	if err != nil {
+		// images of boarding passes have no text, but still have barcodes
+		if decoded, found := FromBarcodes(Barcodes(raw, log), registry, options.Now); found {
+			Enrich(&decoded, registry)
+			trip.Assemble(&decoded, registry)
+			return decoded, nil
+		}
+
+		log.Error("failed to extract text",
+			zap.Error(err),
+		)
+		return
+	}
+
+	finalized, err = ParseText(txt, log, options)
+
+	// looking for barcodes renders pages, it is worth it only for documents which may be boarding passes
+	if !barcodeCandidate(txt) {
+		return
+	}
+
+	decoded, found := FromBarcodes(Barcodes(raw, log), registry, reference(finalized, options.Now))
+	if !found {
+		return
+	}
+
+	finalized = Override(finalized, decoded, registry)
+	Enrich(&finalized, registry)
+	trip.Assemble(&finalized, registry)
+
+	log.Debug("barcodes override text",
+		zap.Any("conflicts", finalized.Conflicts),
+	)
+
+	return finalized, nil
+}
+
+// ParseText classifies text already extracted from a document and looks for airports in it,
+// ErrNotTravel is returned together with the classification for documents which are not travel documents
+func ParseText(txt string, log *zap.Logger, options Options) (finalized model.Itinerary, err error) {
+
+	registry := airport.Default()
+
+	log.Debug("text extracted",
+		zap.Int("characters", len(txt)),
+	)
+	options.Progress.report(StageExtracted, map[string]any{"characters": len(txt)})
+
+	// structured data such as boarding pass strings is more reliable than any heuristics
+	if recognized, source, found := recognize(txt, registry, options.Now); found {
+		log.Debug("itinerary recognized",
+			zap.String("source", source),
+			zap.Int("segments", len(recognized.Segments)),
+		)
+		options.Progress.report(StageCandidates, map[string]any{"source": source, "segments": len(recognized.Segments)})
+
+		finalized = recognized
+		Enrich(&finalized, registry)
+		trip.Assemble(&finalized, registry)
+		return
+	}
+
+	document := classify.Classify(txt)
+	log.Debug("document classified",
+		zap.String("type", document.Type),
+		zap.Float64("confidence", document.Confidence),
+		zap.Any("scores", document.Scores),
+	)
+
+	if !document.Travel {
+		finalized.Document = &document
+		return finalized, ErrNotTravel
+	}
 
-	txt, _ := pdf.PdfToTxt(raw)
-	fileContent, _ := json.Marshal(txt)
-	os.WriteFile("fileContent.json", fileContent, 0744)
 	newTxt := ""
 
 	for _, ch := range txt {
@@ -32,22 +129,28 @@ func Parse(raw []byte, log *zap.Logger) (finalized map[string]any) {
 
 	splits := strings.Split(newTxt, " ")
 
-	raw, _ = ioutil.ReadFile("data/iata.json")
-	iata := make(map[string]map[string]string)
-
-	json.Unmarshal(raw, &iata)
-
 	candidates := make([]string, 0)
 	for _, split := range splits {
 
-		if _, found := iata[split]; found {
+		// codes are printed in upper case, lower case words are just text
+		if split != strings.ToUpper(split) {
+			continue
+		}
+
+		if _, found := registry.IATA(split); found {
+			candidates = append(candidates, split)
+			continue
+		}
+
+		// city codes such as LON are resolved to an airport later
+		if _, found := registry.Metro(split); found {
 			candidates = append(candidates, split)
 		}
 	}
 
 	trie := pdf.TrieData()
 	//Passing the words in the trie
-	raw, _ = ioutil.ReadFile("data/cityLower.json")
+	raw, _ := ioutil.ReadFile("data/cityLower.json")
 	cities := make([]string, 0)
 
 	json.Unmarshal(raw, &cities)
@@ -57,20 +160,9 @@ func Parse(raw []byte, log *zap.Logger) (finalized map[string]any) {
 		trie.Insert(city)
 	}
 
+	schedule := txt
 	txt = strings.Replace(txt, "\n", " ", -1)
 
-	fmt.Println("looking for date matches...")
-
-	dateRegex := regexp.MustCompile(`(\d{1,2}\s+\w+\s+\d{4})`)
-
-	lines := strings.Split(txt, "\n")
-
-	for _, line := range lines {
-		if match := dateRegex.FindStringSubmatch(line); match != nil {
-			fmt.Println(match[1])
-		}
-	}
-
 	txt = strings.Replace(txt, "\t", " ", -1)
 	txt = strings.Replace(txt, "\f", " ", -1)
 
@@ -83,8 +175,6 @@ func Parse(raw []byte, log *zap.Logger) (finalized map[string]any) {
 			continue
 		}
 
-		fmt.Println("wr: ", wr)
-
 		wr = strings.ToLower(wr)
 		found := trie.Search(wr)
 		if found == 1 {
@@ -99,15 +189,17 @@ func Parse(raw []byte, log *zap.Logger) (finalized map[string]any) {
 		zap.Strings("codes", candidates),
 		zap.Strings("cities", foundCities),
 	)
+	options.Progress.report(StageCandidates, map[string]any{"codes": candidates, "cities": foundCities})
 
 	for _, candidate := range candidates {
 
-		if iata[candidate] == nil {
-			continue
+		city := ""
+		if found, ok := registry.IATA(candidate); ok {
+			city = found.City
+		} else if metro, ok := registry.Metro(candidate); ok {
+			city = metro.Name
 		}
 
-		city := iata[candidate]["city"]
-
 		if city == "" {
 			continue
 		}
@@ -128,7 +220,30 @@ func Parse(raw []byte, log *zap.Logger) (finalized map[string]any) {
 		zap.Strings("candidates", finalCandidates),
 	)
 
-	finalized = Finalize(finalCandidates, iata)
+	metros := Disambiguate(schedule, candidates, registry)
+	finalCandidates = Resolve(finalCandidates, metros)
+
+	route := routes[document.Type]
+
+	finalized = Finalize(finalCandidates, registry)
+	finalized.Document = &document
+	finalized.Metros = metros
+	for i := range finalized.Segments {
+		finalized.Segments[i].Source = SourceText
+		finalized.Segments[i].Confidence = textConfidence
+	}
+	if route.schedule {
+		Schedule(schedule, &finalized, registry, options.Now)
+	}
+	Enrich(&finalized, registry)
+	FlightNumbers(schedule, &finalized)
+	if route.passengers {
+		Passengers(schedule, &finalized, registry)
+	}
+	if route.receipt {
+		Receipts(schedule, &finalized)
+	}
+	trip.Assemble(&finalized, registry)
 
 	return
 }
@@ -0,0 +1,415 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airline"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/transform"
+)
+
+const (
+	PassengerAdult  = "adult"
+	PassengerChild  = "child"
+	PassengerInfant = "infant"
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	// Mr JEREMY PHILIP CREIGHTON, MS Florence Lim
+	titledNameRegex = regexp.MustCompile(`\b(?i:(MRS|MR|MS|MISS|MSTR|MASTER|DR|PROF))\.?\s+([A-Z][A-Za-z'\-]+(?:[ \t]+[A-Z][A-Za-z'\-]+){1,3})`)
+	// CREIGHTON/JEREMY PHILIP MR
+	slashNameRegex = regexp.MustCompile(`\b([A-Z][A-Z'\-]+)/([A-Z][A-Z'\-]+(?:[ \t][A-Z][A-Z'\-]+){0,3})\b`)
+	// Passenger: CREIGHTON/JEREMY PHILIP, names without title are taken only next to a label
+	passengerLabelRegex = regexp.MustCompile(`(?i)\b(passengers?|names?|travell?ers?|pax|guests?)\b`)
+
+	infantRegex = regexp.MustCompile(`(?i)\(INF\)|\bINFANT\b`)
+	childRegex  = regexp.MustCompile(`(?i)\(CHD\)|\bCHILD\b`)
+
+	frequentFlyerRegex = regexp.MustCompile(`(?i)\b(krisflyer|asia miles|skywards|executive club|flying blue|miles\s?&\s?more|velocity|qantas frequent flyer|frequent flyer)\s*(?:no\.?|number|#)?\s*:?\s*((?:[A-Z]{2}\s?)?\d{6,12})\b`)
+
+	cabinRegex        = regexp.MustCompile(`(?i)\b(premium economy|economy|business|first)\b`)
+	bookingClassRegex = regexp.MustCompile(`(?i:booking\s+class|fare\s+class|rbd|class)\s*:\s*([A-Z])\b`)
+	seatRegex         = regexp.MustCompile(`(?i:seat)s?\s*(?i:no\.?|number)?\s*:?\s*(\d{1,2}[A-K])\b`)
+	bareSeatRegex     = regexp.MustCompile(`\b(\d{1,2}[A-K])\b`)
+	terminalRegex     = regexp.MustCompile(`(?i)\bterminal\s*:?\s*(\d{1,2}[A-Z]?\b|intl\b|international\b|domestic\b|[A-Z]\b)|\b(T[1-9][A-Z]?)\b`)
+	gateRegex         = regexp.MustCompile(`(?i)\bgate\s*:?\s*([A-Z]?\d{1,3}[A-Z]?)\b`)
+	baggageRegex      = regexp.MustCompile(`(?i)\b(?:checked\s+)?bag(?:gage|s)?(?:\s+allowance)?\s*:?\s*(\d{1,2}\s?kg|\d\s?pcs?|\d\s?pieces?)\b`)
+	weightRegex       = regexp.MustCompile(`(?i)\b(\d{1,2})\s?kg\b`)
+)
+
+// FlightNumbers attaches flight numbers found in text to segments, if there is one flight number per segment
+func FlightNumbers(txt string, itinerary *model.Itinerary) {
+	numbers := make([]string, 0)
+
+	for _, number := range airline.FlightNumbers(txt) {
+		// flight numbers are usually repeated next to each other, e.g. in header and in details
+		if transform.InSlice(number.String(), numbers) {
+			continue
+		}
+		numbers = append(numbers, number.String())
+	}
+
+	if len(numbers) != len(itinerary.Segments) {
+		return
+	}
+
+	for i := range itinerary.Segments {
+		itinerary.Segments[i].FlightNumber = numbers[i]
+	}
+}
+
+// segmentAnchors returns index of the first line of every segment, -1 if segment is not found
+func segmentAnchors(lines []string, segments []model.Segment) []int {
+	anchors := make([]int, len(segments))
+	from := 0
+
+	for i, segment := range segments {
+		anchors[i] = -1
+
+		for l := from; l < len(lines); l++ {
+			found := false
+
+			if segment.FlightNumber != "" {
+				found = strings.Contains(strings.ReplaceAll(lines[l], " ", ""), segment.FlightNumber)
+			} else {
+				departure := strings.Index(lines[l], segment.Departure)
+				arrival := strings.LastIndex(lines[l], segment.Arrival)
+				found = departure != -1 && arrival > departure
+			}
+
+			if found {
+				anchors[i] = l
+				from = l + 1
+				break
+			}
+		}
+	}
+
+	return anchors
+}
+
+// segmentOf returns segment which line belongs to, -1 for lines before the first segment
+func segmentOf(line int, anchors []int) int {
+	segment := -1
+	for i, anchor := range anchors {
+		if anchor != -1 && anchor <= line {
+			segment = i
+		}
+	}
+
+	return segment
+}
+
+// titles printed after names in SURNAME/GIVEN format
+var slashTitles = []string{"MRS", "MR", "MS", "MISS", "MSTR", "DR"}
+
+// words joined by slash which are not names, e.g. AND/OR
+var slashStopWords = []string{"AND", "OR", "IN", "OUT", "ON", "OFF", "YES", "NO", "AM", "PM", "TO", "FROM", "VIA", "NA"}
+
+// passengerName finds passenger name on a line, name is returned in upper case as given name followed by surname.
+// Words joined by slash such as AND/OR and pairs of airport codes such as SIN/DPS are not names.
+func passengerName(line string, registry *airport.Registry) (name, title string, found bool) {
+	for _, match := range slashNameRegex.FindAllStringSubmatch(line, -1) {
+		if stopWords(match[1], match[2]) || airportCodes(match[1], match[2], registry) {
+			continue
+		}
+
+		given := strings.Fields(match[2])
+
+		// title is printed after given names
+		if last := given[len(given)-1]; len(given) > 1 && transform.InSlice(last, slashTitles) {
+			title = last
+			given = given[:len(given)-1]
+		}
+
+		return strings.Join(given, " ") + " " + match[1], title, true
+	}
+
+	if match := titledNameRegex.FindStringSubmatch(line); match != nil {
+		return strings.ToUpper(match[2]), strings.ToUpper(match[1]), true
+	}
+
+	return
+}
+
+// stopWords reports if surname or given names joined by slash are words, not names
+func stopWords(surname, given string) bool {
+	if transform.InSlice(surname, slashStopWords) {
+		return true
+	}
+
+	for _, word := range strings.Fields(given) {
+		if transform.InSlice(word, slashStopWords) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// airportCodes reports if surname and first given name joined by slash are both IATA codes
+func airportCodes(surname, given string, registry *airport.Registry) bool {
+	first := strings.Fields(given)[0]
+	if len(surname) != 3 || len(first) != 3 {
+		return false
+	}
+
+	_, departure := registry.IATA(surname)
+	_, arrival := registry.IATA(first)
+
+	return departure && arrival
+}
+
+// textPassengerName finds passenger name on a line of free text, names without title need a passenger label
+// on the same line
+func textPassengerName(line string, registry *airport.Registry) (name, title string, found bool) {
+	name, title, found = passengerName(line, registry)
+	if !found || (title == "" && !passengerLabelRegex.MatchString(line)) {
+		return "", "", false
+	}
+
+	return name, title, true
+}
+
+// Passengers extracts passengers with their frequent flyer numbers, seats, cabins and baggage,
+// terminals and gates are attached to segments. Details are attached to the passenger and
+// segment they are printed closest after.
+func Passengers(txt string, itinerary *model.Itinerary, registry *airport.Registry) {
+	var (
+		lines    = strings.Split(txt, "\n")
+		anchors  = segmentAnchors(lines, itinerary.Segments)
+		current  = -1
+		table    = false
+		cabins   = make(map[int]string)
+		classes  = make(map[int]string)
+		terminal = make(map[int]int)
+	)
+
+	passengers := make([]model.Passenger, 0)
+
+	// details found before any name belong to the only passenger
+	passenger := func() *model.Passenger {
+		if current == -1 {
+			passengers = append(passengers, model.Passenger{Type: PassengerAdult})
+			current = len(passengers) - 1
+		}
+		return &passengers[current]
+	}
+
+	for l, line := range lines {
+		segment := segmentOf(l, anchors)
+
+		if name, title, found := textPassengerName(line, registry); found {
+			current = findPassenger(passengers, name)
+			if current == -1 {
+				passengers = append(passengers, model.Passenger{
+					Name:  name,
+					Title: title,
+					Type:  passengerType(title, line),
+				})
+				current = len(passengers) - 1
+			} else if passengers[current].Name == "" {
+				passengers[current].Name = name
+				passengers[current].Title = title
+			}
+
+			// passenger rows of a table with seat and baggage columns
+			if table {
+				details := passengerSegment(&passengers[current], segment)
+				if match := bareSeatRegex.FindStringSubmatch(line); match != nil {
+					details.Seat = match[1]
+				}
+				if weights := weightRegex.FindAllStringSubmatch(line, -1); len(weights) > 0 {
+					details.Baggage = weights[len(weights)-1][1] + "kg"
+				}
+			}
+		}
+
+		lower := strings.ToLower(line)
+		if strings.Contains(lower, "seat") && strings.Contains(lower, "baggage") {
+			table = true
+		}
+
+		if match := frequentFlyerRegex.FindStringSubmatch(line); match != nil {
+			p := passenger()
+			p.FrequentFlyer = append(p.FrequentFlyer, model.FrequentFlyer{
+				Program: transform.Title(match[1]),
+				Number:  strings.ReplaceAll(match[2], " ", ""),
+			})
+		}
+
+		if segment == -1 {
+			continue
+		}
+
+		if match := cabinRegex.FindStringSubmatch(line); match != nil && cabins[segment] == "" {
+			cabins[segment] = transform.Title(match[1])
+		}
+
+		if match := bookingClassRegex.FindStringSubmatch(line); match != nil {
+			classes[segment] = match[1]
+		}
+
+		if match := seatRegex.FindStringSubmatch(line); match != nil {
+			passengerSegment(passenger(), segment).Seat = strings.ToUpper(match[1])
+		}
+
+		if match := baggageRegex.FindStringSubmatch(line); match != nil {
+			passengerSegment(passenger(), segment).Baggage = strings.ToLower(strings.ReplaceAll(match[1], " ", ""))
+		}
+
+		if match := gateRegex.FindStringSubmatch(line); match != nil {
+			itinerary.Segments[segment].Gate = strings.ToUpper(match[1])
+		}
+
+		if match := terminalRegex.FindStringSubmatchIndex(line); match != nil {
+			name := terminalName(line, match)
+			s := &itinerary.Segments[segment]
+
+			switch terminalSide(line[:match[0]], s, registry) {
+			case "departure":
+				s.DepartureTerminal = name
+			case "arrival":
+				s.ArrivalTerminal = name
+			default:
+				// without airport next to it, first terminal is where flight departs from
+				if terminal[segment] == 0 {
+					s.DepartureTerminal = name
+				} else {
+					s.ArrivalTerminal = name
+				}
+			}
+			terminal[segment]++
+		}
+	}
+
+	// cabin and booking class are printed once per segment, but apply to every passenger
+	for i := range passengers {
+		for segment := range itinerary.Segments {
+			if cabins[segment] == "" && classes[segment] == "" {
+				continue
+			}
+
+			details := passengerSegment(&passengers[i], segment)
+			details.Cabin = cabins[segment]
+			details.BookingClass = classes[segment]
+		}
+	}
+
+	itinerary.Passengers = passengers
+}
+
+// findPassenger returns index of passenger with name, -1 if there is none
+func findPassenger(passengers []model.Passenger, name string) int {
+	for i, p := range passengers {
+		if p.Name == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// samePassenger reports if names belong to the same passenger, names on boarding passes are cut after 20 characters
+func samePassenger(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == "" || b == "" {
+		return false
+	}
+
+	surnameA, surnameB := a[strings.LastIndex(a, " ")+1:], b[strings.LastIndex(b, " ")+1:]
+	if surnameA != surnameB {
+		return false
+	}
+
+	givenA, givenB := strings.TrimSuffix(a, surnameA), strings.TrimSuffix(b, surnameB)
+	return strings.HasPrefix(givenA, strings.TrimSpace(givenB)) || strings.HasPrefix(givenB, strings.TrimSpace(givenA))
+}
+
+// passengerType tells adult, child or infant from title and markers next to the name
+func passengerType(title, line string) string {
+	switch {
+	case infantRegex.MatchString(line):
+		return PassengerInfant
+	case childRegex.MatchString(line), title == "MSTR", title == "MASTER":
+		return PassengerChild
+	}
+
+	return PassengerAdult
+}
+
+// passengerSegment returns details of passenger on segment, creating them if missing
+func passengerSegment(passenger *model.Passenger, segment int) *model.PassengerSegment {
+	if segment < 0 {
+		segment = 0
+	}
+
+	for i := range passenger.Segments {
+		if passenger.Segments[i].Segment == segment {
+			return &passenger.Segments[i]
+		}
+	}
+
+	passenger.Segments = append(passenger.Segments, model.PassengerSegment{Segment: segment})
+	return &passenger.Segments[len(passenger.Segments)-1]
+}
+
+// terminalName normalizes matched terminal, e.g. "Terminal 2" and "T2" both become "2"
+func terminalName(line string, match []int) string {
+	if match[2] != -1 {
+		name := line[match[2]:match[3]]
+		if strings.EqualFold(name, "international") {
+			return "Intl"
+		}
+		return transform.Title(name)
+	}
+
+	return strings.TrimPrefix(strings.ToUpper(line[match[4]:match[5]]), "T")
+}
+
+// words of airport names which do not tell one airport from another
+var terminalStopWords = []string{"airport", "international", "regional", "terminal", "island"}
+
+// terminalSide tells if text before terminal names departure or arrival airport
+func terminalSide(before string, segment *model.Segment, registry *airport.Registry) string {
+	before = transform.Fold(before)
+	if strings.TrimSpace(before) == "" {
+		return ""
+	}
+
+	words := strings.Fields(before)
+
+	mentions := func(code string) bool {
+		if transform.InSlice(strings.ToLower(code), words) {
+			return true
+		}
+
+		a, found := registry.IATA(code)
+		if !found {
+			return false
+		}
+
+		for _, word := range strings.Fields(transform.Fold(a.City + " " + a.Name)) {
+			if len(word) > 3 && !transform.InSlice(word, terminalStopWords) && strings.Contains(before, word) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case mentions(segment.Departure):
+		return "departure"
+	case mentions(segment.Arrival):
+		return "arrival"
+	}
+
+	return ""
+}
@@ -0,0 +1,252 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/barcode"
+	"trikliq-airport-finder/pkg/classify"
+	"trikliq-airport-finder/pkg/pkpass"
+	"trikliq-airport-finder/pkg/transform"
+	"trikliq-airport-finder/pkg/trip"
+
+	"go.uber.org/zap"
+)
+
+// pkpassConfidence is confidence of segments read from fields of passes, fields are labeled but free text
+const pkpassConfidence = 0.8
+
+//lint:ignore GLOBAL this is okay
+var (
+	passFlightRegex = regexp.MustCompile(`^([A-Z0-9]{2})\s?(\d{1,4}[A-Z]?)$`)
+	// words of keys and labels, e.g. "departureGate" and "Depart Gate" are both "depart" and "gate"
+	passWordRegex = regexp.MustCompile(`[A-Z]?[a-z]+|[A-Z]+`)
+)
+
+// Pkpass reads itinerary from Apple Wallet passes, BCBP barcodes of passes override their fields
+func Pkpass(raw []byte, log *zap.Logger, options Options) (finalized model.Itinerary, err error) {
+	passes, err := pkpass.Read(raw)
+	if err != nil {
+		log.Warn("failed to read pass",
+			zap.Error(err),
+		)
+		return
+	}
+
+	registry := airport.Default()
+	finalized = fromPasses(passes, registry)
+
+	barcodes := make([]barcode.Result, 0)
+	for _, pass := range passes {
+		for _, message := range pass.Messages() {
+			barcodes = append(barcodes, barcode.Result{Text: message})
+		}
+	}
+
+	if decoded, found := FromBarcodes(barcodes, registry, reference(finalized, options.Now)); found {
+		finalized = Override(finalized, decoded, registry)
+	}
+
+	log.Debug("passes read",
+		zap.Int("passes", len(passes)),
+		zap.Int("segments", len(finalized.Segments)),
+	)
+
+	if len(finalized.Segments) == 0 {
+		finalized.Document = &classify.Document{Type: classify.Other, Confidence: 1}
+		return finalized, ErrNotTravel
+	}
+
+	Enrich(&finalized, registry)
+	trip.Assemble(&finalized, registry)
+
+	return
+}
+
+// fromPasses maps fields of boarding passes onto itinerary, passes of other kinds are skipped
+func fromPasses(passes []*pkpass.Pass, registry *airport.Registry) model.Itinerary {
+	var (
+		codes      = make([]string, 0)
+		segments   = make([]model.Segment, 0)
+		passengers = make([]model.Passenger, 0)
+	)
+
+	for _, pass := range passes {
+		if pass.BoardingPass == nil || pass.BoardingPass.TransitType != pkpass.TransitAir {
+			continue
+		}
+
+		segment, details, name := passSegment(pass, registry)
+		if segment.Departure == "" || segment.Arrival == "" {
+			continue
+		}
+
+		s := findSegment(segments, segment)
+		if s == -1 {
+			segments = append(segments, segment)
+			codes = append(codes, segment.Departure, segment.Arrival)
+			s = len(segments) - 1
+		}
+
+		if name == "" {
+			continue
+		}
+
+		p := findPassenger(passengers, name)
+		if p == -1 {
+			passengers = append(passengers, model.Passenger{Name: name, Type: PassengerAdult})
+			p = len(passengers) - 1
+		}
+
+		details.Segment = s
+		*passengerSegment(&passengers[p], s) = details
+	}
+
+	itinerary := Finalize(codes, registry)
+	itinerary.Segments = segments
+	itinerary.Passengers = passengers
+	itinerary.Document = &classify.Document{
+		Type:       classify.BoardingPass,
+		Confidence: 1,
+		Travel:     true,
+	}
+
+	return itinerary
+}
+
+// passSegment reads segment and passenger from fields, fields are recognized by their keys and labels
+func passSegment(pass *pkpass.Pass, registry *airport.Registry) (segment model.Segment, details model.PassengerSegment, name string) {
+	segment.Source = SourcePkpass
+	segment.Confidence = pkpassConfidence
+
+	if date, ok := passTime(pass.RelevantDate); ok {
+		segment.Date = date.Format("2006-01-02")
+	}
+
+	airports := make([]string, 0)
+
+	for _, field := range pass.BoardingPass.All() {
+		var (
+			key   = passWords(field.Key + " " + field.Label)
+			value = strings.TrimSpace(field.String())
+			upper = strings.ToUpper(value)
+		)
+
+		if value == "" {
+			continue
+		}
+
+		if _, found := registry.IATA(upper); found && len(upper) == 3 {
+			switch {
+			case key.starts("orig", "depart") || key.is("from"):
+				segment.Departure = upper
+			case key.starts("dest", "arriv") || key.is("to"):
+				segment.Arrival = upper
+			default:
+				airports = append(airports, upper)
+			}
+			continue
+		}
+
+		switch {
+		case key.starts("flight"):
+			if match := passFlightRegex.FindStringSubmatch(strings.ReplaceAll(upper, " ", "")); match != nil {
+				segment.FlightNumber = match[1] + match[2]
+			}
+
+		case key.starts("seat"):
+			details.Seat = upper
+
+		case key.starts("gate"):
+			segment.Gate = upper
+
+		case key.starts("terminal"):
+			segment.DepartureTerminal = strings.TrimPrefix(upper, "T")
+
+		case key.starts("class", "cabin"):
+			if match := cabinRegex.FindStringSubmatch(value); match != nil {
+				details.Cabin = transform.Title(match[1])
+			} else if len(upper) == 1 {
+				details.BookingClass = upper
+			}
+
+		case key.starts("passenger", "name"):
+			if n, _, found := passengerName(upper, registry); found {
+				name = n
+			} else {
+				name = upper
+			}
+
+		case key.starts("depart", "boarding"):
+			if t, ok := passTime(value); ok {
+				segment.Date = t.Format("2006-01-02")
+				if key.starts("depart") {
+					segment.DepartureTime = t.Format("15:04")
+				}
+			}
+		}
+	}
+
+	// airports without a telling label are printed in order of travel
+	for _, code := range airports {
+		switch {
+		case segment.Departure == "":
+			segment.Departure = code
+		case segment.Arrival == "" && code != segment.Departure:
+			segment.Arrival = code
+		}
+	}
+
+	return
+}
+
+// passKey is lower case words of key and label of a field
+type passKey []string
+
+// passWords splits key and label of field into words
+func passWords(text string) passKey {
+	found := passWordRegex.FindAllString(text, -1)
+	for i := range found {
+		found[i] = strings.ToLower(found[i])
+	}
+
+	return found
+}
+
+// starts reports if any word starts with one of stems, e.g. "departure" starts with "depart"
+func (k passKey) starts(stems ...string) bool {
+	for _, word := range k {
+		for _, stem := range stems {
+			if strings.HasPrefix(word, stem) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// is reports if any word is one of short words, which would be prefixes of unrelated words
+func (k passKey) is(short ...string) bool {
+	for _, word := range k {
+		if transform.InSlice(word, short) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// passTime parses dates of passes, which are W3C dates with or without seconds
+func passTime(value string) (time.Time, bool) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", "2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+
+	return time.Time{}, false
+}
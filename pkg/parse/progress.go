@@ -0,0 +1,35 @@
+package parse
+
+import "time"
+
+// Options of reading a document
+type Options struct {
+	// Now is when the document is read, segments without printed date or year are dated after it
+	Now time.Time
+	// Progress is told stages of reading, if set
+	Progress Observer
+}
+
+// stages of reading a document, parsers report them to Observer
+const (
+	StageReceived   = "received"
+	StageExtracted  = "extracted"
+	StageCandidates = "candidates"
+	StageDone       = "done"
+	StageError      = "error"
+)
+
+// Event is a stage of reading a document with what was found at it
+type Event struct {
+	Stage  string
+	Fields map[string]any
+}
+
+// Observer is told stages of reading a document by the goroutine reading it, nil Observer is told nothing
+type Observer func(Event)
+
+func (o Observer) report(stage string, fields map[string]any) {
+	if o != nil {
+		o(Event{Stage: stage, Fields: fields})
+	}
+}
@@ -0,0 +1,393 @@
+package parse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/currency"
+	"trikliq-airport-finder/pkg/transform"
+)
+
+// labelWindow is how far after its label a value is looked for, values are often on the next line
+const labelWindow = 60
+
+// types of itemised charges
+const (
+	chargeTax     = "tax"
+	chargeBaggage = "baggage"
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	// BOOKING REFERENCE: 6GIY5Q, PNR 6GIY5Q
+	reservationRegex = regexp.MustCompile(`(?i:booking\s+ref(?:erence)?|booking\s+code|reservation\s+(?:code|number)|confirmation\s+(?:code|number)|record\s+locator|airline\s+reference|pnr)\s*(?i:no\.?|number)?\s*:?\s*\b([A-Z0-9]{6})\b`)
+	// 618-2438794256, 618 2438794256, 6182438794256
+	ticketNumberRegex = regexp.MustCompile(`\b(\d{3})[ -]?(\d{10})\b`)
+	ticketRegex       = regexp.MustCompile(`(?i)\b(?:e-?ticket|ticket|etkt|tkt)\b`)
+	issueRegex        = regexp.MustCompile(`(?i)\b(?:date\s+of\s+issue|issue\s+date|date\s+issued|issued\s+on|issued)\s*:?`)
+
+	totalRegex = regexp.MustCompile(`(?i)\b(?:grand\s+total|total\s+amount|total\s+price|total\s+paid|amount\s+paid|total\s+charged|total)\b\s*:?`)
+	fareRegex  = regexp.MustCompile(`(?i)\b(?:ticket\s+fare|base\s+fare|air\s?fare|fare\s+amount|fare)\b\s*:?`)
+	taxesRegex = regexp.MustCompile(`(?i)\b(?:taxes,?\s+fees\s+(?:and|&)\s+(?:carrier\s+)?(?:surcharges|charges)|taxes\s+(?:and|&)\s+(?:fees|charges|surcharges)|taxes|tax)\b\s*:?`)
+	// Airport Development Levy, Passenger Service Charge
+	chargeRegex = regexp.MustCompile(`(?i)\b(?:levy|fee|charge|surcharge|tax|duty)\b`)
+	// Checked bag 1 x 23kg, Excess baggage
+	paidBaggageRegex = regexp.MustCompile(`(?i)\b(?:baggage|bags?|luggage)\b`)
+	// Change fee, No show fee, Refund fee, fees of conditions of fare which were not paid
+	penaltyRegex = regexp.MustCompile(`(?i)\b(?:change|changes|rebooking|reissue|amendment|cancell?ation|cancel|no[ \t-]?show|refund|penalty)\b`)
+
+	// SGD 74.70, 74.70 SGD, €1.234,56, 23,500 miles
+	amountRegex = regexp.MustCompile(`(?:\b([A-Z]{3})[ \t]?|([$€£¥₹])[ \t]?)?(\d{1,3}(?:[,.']\d{3})+(?:[.,]\d{1,2})?|\d+(?:[.,]\d{1,2})?)\b(?:[ \t]?([A-Z]{3})\b)?([ \t]*(?i:miles|avios|points|pts)\b)?`)
+	// amount alone on its line, or ending the line of its label
+	bareAmountRegex = regexp.MustCompile(`^(?:[A-Z]{3}[ \t]?)?(\d{1,3}(?:[,.']\d{3})+(?:[.,]\d{1,2})?|\d+(?:[.,]\d{1,2})?)(?:[ \t]?[A-Z]{3})?$`)
+	lineAmountRegex = regexp.MustCompile(`^(.*?\D)[ \t]+(?:[A-Z]{3}[ \t]?)?(\d{1,3}(?:[,.']\d{3})*[.,]\d{2})(?:[ \t]?[A-Z]{3})?$`)
+)
+
+// symbols of currencies which are not shared by several currencies, $ and ¥ are
+var symbols = map[string]string{"€": "EUR", "£": "GBP", "₹": "INR"}
+
+// money is an amount found in text, currency is empty if it was not printed next to it
+type money struct {
+	offset   int
+	currency string
+	amount   float64
+	miles    bool
+}
+
+// Receipts reads payment of tickets: fare, taxes and total with their currency, ticket numbers and booking
+// reference. Segments without reservation get booking reference of the receipt.
+func Receipts(txt string, itinerary *model.Itinerary) {
+	receipt := model.Receipt{
+		Reservation:   bookingReference(txt),
+		TicketNumbers: ticketNumbers(txt),
+		IssueDate:     issueDate(txt),
+		Charges:       charges(txt),
+	}
+
+	var (
+		totals = labelled(txt, totalRegex)
+		fares  = labelled(txt, fareRegex)
+		taxes  = labelled(txt, taxesRegex)
+	)
+
+	// fares paid with miles are printed in miles and again without unit
+	for _, m := range append(totals, fares...) {
+		if m.miles && int(m.amount) > receipt.Miles {
+			receipt.Miles = int(m.amount)
+		}
+	}
+
+	total, totalCurrency := cash(totals, receipt.Miles)
+	fare, fareCurrency := cash(fares, receipt.Miles)
+	tax, taxCurrency := cash(taxes, receipt.Miles)
+
+	receipt.Currency = firstOf(totalCurrency, fareCurrency, taxCurrency, printedCurrency(txt))
+	receipt.Total, receipt.Fare, receipt.Taxes = total, fare, tax
+
+	var extras float64
+	for _, c := range receipt.Charges {
+		if c.Type == chargeBaggage {
+			extras += c.Amount
+		}
+	}
+	if receipt.Taxes == 0 {
+		for _, c := range receipt.Charges {
+			if c.Type == chargeTax {
+				receipt.Taxes += c.Amount
+			}
+		}
+	}
+	if receipt.Total == 0 && receipt.Fare > 0 {
+		receipt.Total = receipt.Fare + receipt.Taxes + extras
+	}
+	if c, found := currency.Get(receipt.Currency); found {
+		receipt.Fare, receipt.Taxes, receipt.Total = c.Round(receipt.Fare), c.Round(receipt.Taxes), c.Round(receipt.Total)
+	}
+
+	if receipt.Reservation != "" {
+		for i := range itinerary.Segments {
+			if itinerary.Segments[i].Reservation == "" {
+				itinerary.Segments[i].Reservation = receipt.Reservation
+			}
+		}
+	}
+
+	if receipt.Reservation == "" && len(receipt.TicketNumbers) == 0 && receipt.Total == 0 && receipt.Miles == 0 {
+		return
+	}
+
+	itinerary.Receipt = &receipt
+}
+
+// bookingReference returns the first labelled booking reference, references have at least one letter
+func bookingReference(txt string) string {
+	for _, match := range reservationRegex.FindAllStringSubmatch(txt, -1) {
+		if strings.ContainsAny(match[1], "ABCDEFGHIJKLMNOPQRSTUVWXYZ") {
+			return match[1]
+		}
+	}
+
+	return ""
+}
+
+// ticketNumbers returns 13 digit ticket numbers which are printed near a mention of ticket, in order of appearance
+func ticketNumbers(txt string) []string {
+	numbers := make([]string, 0)
+
+	for _, match := range ticketNumberRegex.FindAllStringSubmatchIndex(txt, -1) {
+		// layout of PDFs often puts label after its value
+		from, to := match[0]-labelWindow*2, match[1]+labelWindow*2
+		if from < 0 {
+			from = 0
+		}
+		if to > len(txt) {
+			to = len(txt)
+		}
+		if !ticketRegex.MatchString(txt[from:to]) {
+			continue
+		}
+
+		number := txt[match[2]:match[3]] + txt[match[4]:match[5]]
+		if !transform.InSlice(number, numbers) {
+			numbers = append(numbers, number)
+		}
+	}
+
+	return numbers
+}
+
+// issueDate returns labelled date of issue as 2006-01-02
+func issueDate(txt string) string {
+	location := issueRegex.FindStringIndex(txt)
+	if location == nil {
+		return ""
+	}
+
+	dates := extractDates(txt[location[1]:window(txt, location[1])])
+	if len(dates) == 0 {
+		return ""
+	}
+
+	return dates[0].Format("2006-01-02")
+}
+
+// labelled returns amounts printed right after the first label which is followed by one, the value may be
+// on the next line
+func labelled(txt string, label *regexp.Regexp) []money {
+	for _, location := range label.FindAllStringIndex(txt, -1) {
+		value := strings.TrimLeft(txt[location[1]:window(txt, location[1])], " \t\r\n")
+		if end := strings.IndexByte(value, '\n'); end != -1 {
+			value = value[:end]
+		}
+
+		// labels are words of sentences too, e.g. conditions of your fare
+		found := amounts(value)
+		if len(found) > 0 && found[0].offset == 0 {
+			return found
+		}
+	}
+
+	return nil
+}
+
+// cash returns the first amount of money, amounts of miles are skipped and so are amounts without unit repeating them
+func cash(found []money, miles int) (float64, string) {
+	for _, m := range found {
+		if m.miles || (m.currency == "" && miles > 0 && int(m.amount) == miles) {
+			continue
+		}
+
+		return m.amount, m.currency
+	}
+
+	return 0, ""
+}
+
+// charges returns itemised taxes, fees and paid baggage, their amounts end their line or are alone on the next line.
+// Fees of changes, cancellations and refunds are conditions of fare, not charges of receipt.
+func charges(txt string) []model.Charge {
+	var (
+		result = make([]model.Charge, 0)
+		lines  = strings.Split(txt, "\n")
+	)
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		charge, baggage := chargeRegex.MatchString(line), paidBaggageRegex.MatchString(line)
+		if !(charge || baggage) || penaltyRegex.MatchString(line) || taxesRegex.FindString(line) == line || totalRegex.MatchString(line) {
+			continue
+		}
+
+		kind := chargeTax
+		if baggage {
+			kind = chargeBaggage
+		}
+
+		if match := lineAmountRegex.FindStringSubmatch(line); match != nil {
+			result = append(result, model.Charge{Name: strings.TrimSpace(strings.TrimRight(match[1], ":")), Type: kind, Amount: parseAmount(match[2])})
+			continue
+		}
+
+		// names of charges have no digits, e.g. dates or flight numbers
+		if strings.ContainsAny(line, "0123456789") {
+			continue
+		}
+
+		// rows of rendered tables are separated by empty lines
+		for _, next := range lines[i+1:] {
+			if next = strings.TrimSpace(next); next == "" {
+				continue
+			}
+			// baggage allowances are printed in pieces or kilograms, paid baggage has decimals
+			if match := bareAmountRegex.FindStringSubmatch(next); match != nil && (charge || strings.ContainsAny(match[1], ".,")) {
+				result = append(result, model.Charge{Name: strings.TrimRight(line, ": "), Type: kind, Amount: parseAmount(match[1])})
+			}
+			break
+		}
+	}
+
+	return result
+}
+
+// amounts returns amounts of text with currency printed before or after them. Numbers without currency
+// or unit are amounts only if they have decimals or thousands, e.g. 74.70 or 23,500 but not 2 passengers.
+func amounts(txt string) []money {
+	found := make([]money, 0)
+
+	for _, match := range amountRegex.FindAllStringSubmatchIndex(txt, -1) {
+		group := func(i int) string {
+			if match[2*i] == -1 {
+				return ""
+			}
+			return txt[match[2*i]:match[2*i+1]]
+		}
+
+		// times and dates, e.g. 2:45 or 12/03
+		if match[1] < len(txt) && strings.ContainsRune(":/", rune(txt[match[1]])) {
+			continue
+		}
+
+		m := money{
+			offset:   match[0],
+			amount:   parseAmount(group(3)),
+			miles:    group(5) != "",
+			currency: symbols[group(2)],
+		}
+
+		for _, code := range []string{group(1), group(4)} {
+			if _, known := currency.Get(code); known {
+				m.currency = code
+			}
+		}
+
+		if m.currency == "" && !m.miles && !strings.ContainsAny(group(3), ".,'") {
+			continue
+		}
+
+		found = append(found, m)
+	}
+
+	return found
+}
+
+// printedCurrency returns the first known currency code printed next to an amount
+func printedCurrency(txt string) string {
+	for _, m := range amounts(txt) {
+		if m.currency != "" {
+			return m.currency
+		}
+	}
+
+	return ""
+}
+
+// parseAmount reads amounts with either decimal separator, separators followed by three digits group thousands
+func parseAmount(value string) float64 {
+	value = strings.ReplaceAll(value, "'", "")
+
+	decimals := strings.LastIndexAny(value, ".,")
+	if decimals != -1 && len(value)-decimals-1 == 3 {
+		decimals = -1
+	}
+
+	var b strings.Builder
+	for i, r := range value {
+		switch {
+		case i == decimals:
+			b.WriteRune('.')
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		}
+	}
+
+	amount, _ := strconv.ParseFloat(b.String(), 64)
+	return amount
+}
+
+// window returns end of text searched for value of label ending at offset
+func window(txt string, offset int) int {
+	if offset+labelWindow > len(txt) {
+		return len(txt)
+	}
+
+	return offset + labelWindow
+}
+
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// mergeReceipt fills fields missing from decoded receipt with those read from text, ticket numbers of both are kept
+func mergeReceipt(decoded, text *model.Receipt) *model.Receipt {
+	if decoded == nil {
+		return text
+	}
+	if text == nil {
+		return decoded
+	}
+
+	merged := *decoded
+	merged.TicketNumbers = append([]string(nil), decoded.TicketNumbers...)
+	for _, number := range text.TicketNumbers {
+		if !transform.InSlice(number, merged.TicketNumbers) {
+			merged.TicketNumbers = append(merged.TicketNumbers, number)
+		}
+	}
+
+	if merged.Reservation == "" {
+		merged.Reservation = text.Reservation
+	}
+	if merged.IssueDate == "" {
+		merged.IssueDate = text.IssueDate
+	}
+	if merged.Miles == 0 {
+		merged.Miles = text.Miles
+	}
+	if len(merged.Charges) == 0 {
+		merged.Charges = text.Charges
+	}
+
+	// amounts belong to their currency, amounts of text in another currency are not mixed in
+	if merged.Currency == "" || merged.Currency == text.Currency {
+		merged.Currency = firstOf(merged.Currency, text.Currency)
+		if merged.Fare == 0 {
+			merged.Fare = text.Fare
+		}
+		if merged.Taxes == 0 {
+			merged.Taxes = text.Taxes
+		}
+		if merged.Total == 0 {
+			merged.Total = text.Total
+		}
+	}
+
+	return &merged
+}
@@ -0,0 +1,58 @@
+package parse
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"trikliq-airport-finder/internal/model"
+)
+
+func TestReceiptPaidBaggage(t *testing.T) {
+	// currencies are read from data of the repository
+	dir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(filepath.Join("..", "..")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(dir)
+	})
+
+	txt := "Booking reference: 6GIY5Q\n" +
+		"Fare EUR 120.00\n" +
+		"Airport charge EUR 18.50\n" +
+		"Checked bag 1 x 23kg EUR 45.00\n" +
+		"Excess baggage\n\nEUR 30.00\n" +
+		"Baggage allowance\n\n1\n" +
+		"Change fee EUR 70.00\n" +
+		"No show fee EUR 50.00\n"
+
+	var itinerary model.Itinerary
+	Receipts(txt, &itinerary)
+	if itinerary.Receipt == nil {
+		t.Fatal("got no receipt")
+	}
+
+	want := []model.Charge{
+		{Name: "Airport charge", Type: chargeTax, Amount: 18.5},
+		{Name: "Checked bag 1 x 23kg", Type: chargeBaggage, Amount: 45},
+		{Name: "Excess baggage", Type: chargeBaggage, Amount: 30},
+	}
+	receipt := itinerary.Receipt
+	if len(receipt.Charges) != len(want) {
+		t.Fatalf("got charges %+v, want %+v", receipt.Charges, want)
+	}
+	for i := range want {
+		if receipt.Charges[i] != want[i] {
+			t.Errorf("got charge %+v, want %+v", receipt.Charges[i], want[i])
+		}
+	}
+
+	// paid baggage is part of total but not of taxes
+	if receipt.Taxes != 18.5 || receipt.Total != 213.5 {
+		t.Errorf("got taxes %v and total %v", receipt.Taxes, receipt.Total)
+	}
+}
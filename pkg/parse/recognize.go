@@ -0,0 +1,59 @@
+package parse
+
+import (
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/bcbp"
+)
+
+// sources of segments
+const (
+	SourceText    = "text"
+	SourceBCBP    = "bcbp"
+	SourceBarcode = "barcode"
+	SourcePkpass  = "pkpass"
+	SourceSchema  = "schema.org"
+	SourceGDS     = "gds"
+
+	// SourceAirportName is a source of airports found by searching the registry for their name
+	SourceAirportName = "airport name"
+)
+
+// textConfidence is confidence of segments found by heuristics in plain text
+const textConfidence = 0.5
+
+// Recognizer finds itinerary in structured data embedded in text, such as boarding pass strings
+type Recognizer struct {
+	Source    string
+	Recognize func(txt string, registry *airport.Registry, now time.Time) (model.Itinerary, bool)
+}
+
+// recognizers in order of priority, itinerary of the first one recognizing text is used instead of heuristics
+//
+//lint:ignore GLOBAL this is okay
+var recognizers = []Recognizer{
+	{Source: SourceBCBP, Recognize: recognizeBCBP},
+	{Source: SourceGDS, Recognize: recognizeGDS},
+}
+
+// recognize runs recognizers in order of priority, now dates what has no date of its own
+func recognize(txt string, registry *airport.Registry, now time.Time) (itinerary model.Itinerary, source string, found bool) {
+	for _, r := range recognizers {
+		if itinerary, found = r.Recognize(txt, registry, now); found {
+			return itinerary, r.Source, true
+		}
+	}
+
+	return
+}
+
+func recognizeBCBP(txt string, registry *airport.Registry, now time.Time) (model.Itinerary, bool) {
+	passes := bcbp.Find(txt)
+	if len(passes) == 0 {
+		return model.Itinerary{}, false
+	}
+
+	return FromBoardingPasses(passes, registry, now), true
+}
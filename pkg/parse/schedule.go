@@ -0,0 +1,251 @@
+package parse
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	// 12 March 2023, 12 Mar 23, 12MAR23, Sun 12 Mar 2023
+	dayMonthYearRegex = regexp.MustCompile(`(?i)\b(\d{1,2})\s*([a-z]{3,9})\.?,?\s*(\d{4}|\d{2})\b`)
+	// March 12, 2023
+	monthDayYearRegex = regexp.MustCompile(`(?i)\b([a-z]{3,9})\.?\s+(\d{1,2}),?\s+(\d{4})\b`)
+	// 2023-03-12
+	isoDateRegex = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	// 12/03/2023, day first
+	slashDateRegex = regexp.MustCompile(`\b(\d{1,2})[/.](\d{1,2})[/.](\d{4})\b`)
+
+	// 09:30, 9:30 pm
+	clockRegex = regexp.MustCompile(`(?i)\b([01]?\d|2[0-3]):([0-5]\d)(?:\s*([ap])\.?m\.?)?\b`)
+	// 0930hrs, 0930H
+	militaryRegex = regexp.MustCompile(`(?i)\b([01]\d|2[0-3])([0-5]\d)\s*(?:hrs|h)\b`)
+
+	// 2h 55m, 2 hrs 55 mins, 2hr55min
+	durationRegex = regexp.MustCompile(`(?i)\b(\d{1,2})\s*(?:h|hr|hrs|hours?)\s*(\d{1,2})\s*(?:m|min|mins|minutes?)\b`)
+)
+
+// labels of dates which are not dates of travel
+var bookkeepingLabels = []string{"issue", "booking", "booked", "printed", "payment", "received", "date:"}
+
+// bookkeeping reports if date at offset is labeled as issue or booking date
+func bookkeeping(txt string, offset int) bool {
+	start := offset - 30
+	if start < 0 {
+		start = 0
+	}
+
+	label := strings.ToLower(txt[start:offset])
+	for _, l := range bookkeepingLabels {
+		if strings.Contains(label, l) {
+			return true
+		}
+	}
+
+	return false
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// month resolves english month name or its abbreviation
+func month(name string) (time.Month, bool) {
+	name = strings.ToLower(name)
+	if len(name) < 3 {
+		return 0, false
+	}
+
+	m, found := months[name[:3]]
+	if !found {
+		return 0, false
+	}
+
+	// reject words which only start like a month, e.g. "marked"
+	full := strings.ToLower(m.String())
+	if len(name) > 3 && !strings.HasPrefix(full, name) && name != "sept" {
+		return 0, false
+	}
+
+	return m, true
+}
+
+// year expands two digit years
+func year(value string) int {
+	y, _ := strconv.Atoi(value)
+	if y < 100 {
+		y += 2000
+	}
+
+	return y
+}
+
+// positioned keeps a value together with its offset in text, so values of different patterns can be ordered
+type positioned struct {
+	offset int
+	value  int
+	date   time.Time
+}
+
+// extractDates returns dates of travel in order of appearance, repeated dates are returned once
+func extractDates(txt string) []time.Time {
+	found := make([]positioned, 0)
+
+	add := func(offset, y int, m time.Month, d int) {
+		if d < 1 || d > 31 || y < 1990 || y > 2100 || bookkeeping(txt, offset) {
+			return
+		}
+
+		date := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+		if date.Day() != d {
+			return
+		}
+
+		found = append(found, positioned{offset: offset, date: date})
+	}
+
+	for _, match := range dayMonthYearRegex.FindAllStringSubmatchIndex(txt, -1) {
+		m, ok := month(txt[match[4]:match[5]])
+		if !ok {
+			continue
+		}
+		d, _ := strconv.Atoi(txt[match[2]:match[3]])
+		add(match[0], year(txt[match[6]:match[7]]), m, d)
+	}
+
+	for _, match := range monthDayYearRegex.FindAllStringSubmatchIndex(txt, -1) {
+		m, ok := month(txt[match[2]:match[3]])
+		if !ok {
+			continue
+		}
+		d, _ := strconv.Atoi(txt[match[4]:match[5]])
+		add(match[0], year(txt[match[6]:match[7]]), m, d)
+	}
+
+	for _, match := range isoDateRegex.FindAllStringSubmatchIndex(txt, -1) {
+		m, _ := strconv.Atoi(txt[match[4]:match[5]])
+		d, _ := strconv.Atoi(txt[match[6]:match[7]])
+		if m < 1 || m > 12 {
+			continue
+		}
+		add(match[0], year(txt[match[2]:match[3]]), time.Month(m), d)
+	}
+
+	for _, match := range slashDateRegex.FindAllStringSubmatchIndex(txt, -1) {
+		d, _ := strconv.Atoi(txt[match[2]:match[3]])
+		m, _ := strconv.Atoi(txt[match[4]:match[5]])
+		if m < 1 || m > 12 {
+			continue
+		}
+		add(match[0], year(txt[match[6]:match[7]]), time.Month(m), d)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].offset < found[j].offset
+	})
+
+	dates := make([]time.Time, 0, len(found))
+	for _, f := range found {
+		// departure and arrival dates are usually printed next to each other
+		if len(dates) > 0 && dates[len(dates)-1].Equal(f.date) {
+			continue
+		}
+		dates = append(dates, f.date)
+	}
+
+	return dates
+}
+
+// extractTimes returns clock times as minutes after midnight, in order of appearance
+func extractTimes(txt string) []int {
+	found := make([]positioned, 0)
+
+	for _, match := range clockRegex.FindAllStringSubmatchIndex(txt, -1) {
+		h, _ := strconv.Atoi(txt[match[2]:match[3]])
+		m, _ := strconv.Atoi(txt[match[4]:match[5]])
+
+		if match[6] != -1 {
+			if h > 12 {
+				continue
+			}
+
+			pm := strings.EqualFold(txt[match[6]:match[7]], "p")
+			if pm && h != 12 {
+				h += 12
+			}
+			if !pm && h == 12 {
+				h = 0
+			}
+		}
+
+		found = append(found, positioned{offset: match[0], value: h*60 + m})
+	}
+
+	for _, match := range militaryRegex.FindAllStringSubmatchIndex(txt, -1) {
+		h, _ := strconv.Atoi(txt[match[2]:match[3]])
+		m, _ := strconv.Atoi(txt[match[4]:match[5]])
+		found = append(found, positioned{offset: match[0], value: h*60 + m})
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		return found[i].offset < found[j].offset
+	})
+
+	times := make([]int, 0, len(found))
+	for _, f := range found {
+		times = append(times, f.value)
+	}
+
+	return times
+}
+
+// extractDurations returns flight durations in minutes, in order of appearance
+func extractDurations(txt string) []int {
+	durations := make([]int, 0)
+
+	for _, match := range durationRegex.FindAllStringSubmatch(txt, -1) {
+		h, _ := strconv.Atoi(match[1])
+		m, _ := strconv.Atoi(match[2])
+		if m > 59 {
+			continue
+		}
+
+		durations = append(durations, h*60+m)
+	}
+
+	return durations
+}
+
+// clock formats minutes after midnight as 15:04
+func clock(minutes int) string {
+	return time.Date(0, 1, 1, 0, minutes, 0, 0, time.UTC).Format("15:04")
+}
+
+// blockMinutes returns time between local departure and local arrival, taking time zones into account
+func blockMinutes(date time.Time, departure, arrival int, departureTz, arrivalTz string) int {
+	departureLocation, err := time.LoadLocation(departureTz)
+	if err != nil {
+		departureLocation = time.UTC
+	}
+
+	arrivalLocation, err := time.LoadLocation(arrivalTz)
+	if err != nil {
+		arrivalLocation = time.UTC
+	}
+
+	y, m, d := date.Date()
+	off := time.Date(y, m, d, 0, departure, 0, 0, departureLocation)
+	on := time.Date(y, m, d, 0, arrival, 0, 0, arrivalLocation)
+
+	// arrival printed in local time may be on the next day
+	for days := 0; on.Before(off) && days < 2; days++ {
+		on = on.AddDate(0, 0, 1)
+	}
+
+	return int(on.Sub(off).Minutes())
+}
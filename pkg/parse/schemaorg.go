@@ -0,0 +1,259 @@
+package parse
+
+import (
+	"regexp"
+	"strings"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/classify"
+	"trikliq-airport-finder/pkg/currency"
+	"trikliq-airport-finder/pkg/schemaorg"
+	"trikliq-airport-finder/pkg/transform"
+)
+
+// schemaConfidence is confidence of segments read from schema.org markup, markup is structured
+// but nobody proofreads it the way printed tickets are
+const schemaConfidence = 0.9
+
+//lint:ignore GLOBAL this is okay
+var (
+	// 2022-11-29T16:20:00+08:00, 2022-11-29T08:20Z
+	zoneRegex = regexp.MustCompile(`(?:Z|[+-]\d{2}:?\d{2})$`)
+	// 944, SQ944, SQ 944
+	schemaFlightRegex = regexp.MustCompile(`^([A-Z][A-Z0-9]|[0-9][A-Z])?\s?(\d{1,4}[A-Z]?)$`)
+	// dataset has placeholders such as --- and 0 where airports have no IATA code
+	iataCodeRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// FromReservations maps schema.org flight reservations onto itinerary, passengers sharing a flight share its segment.
+// Airports are cross-checked against the registry, airports given only by name are searched for and reservations
+// of unknown airports or cancelled reservations are skipped.
+func FromReservations(reservations []schemaorg.Reservation, registry *airport.Registry) (model.Itinerary, bool) {
+	var (
+		codes      = make([]string, 0)
+		segments   = make([]model.Segment, 0)
+		passengers = make([]model.Passenger, 0)
+		receipt    = model.Receipt{TicketNumbers: make([]string, 0)}
+		priced     = make(map[string]bool)
+		conflicts  = make([]model.Conflict, 0)
+	)
+
+	for _, r := range reservations {
+		if r.Cancelled() {
+			continue
+		}
+
+		segment, searched, found := reservationSegment(r.Flight, registry)
+		if !found {
+			continue
+		}
+		segment.Reservation = r.ReservationNumber
+		reservationPrice(&receipt, r, priced)
+
+		s := findSegment(segments, segment)
+		if s == -1 {
+			segments = append(segments, segment)
+			codes = append(codes, segment.Departure, segment.Arrival)
+			s = len(segments) - 1
+
+			for _, c := range searched {
+				c.Segment = s
+				conflicts = append(conflicts, c)
+			}
+		}
+
+		if r.Passenger == "" {
+			continue
+		}
+
+		name, title, found := passengerName(r.Passenger, registry)
+		if !found {
+			name = strings.ToUpper(r.Passenger)
+		}
+
+		p := findPassenger(passengers, name)
+		if p == -1 {
+			passengers = append(passengers, model.Passenger{Name: name, Title: title, Type: PassengerAdult})
+			p = len(passengers) - 1
+		}
+
+		details := passengerSegment(&passengers[p], s)
+		details.Seat = strings.ToUpper(r.Seat)
+		if match := cabinRegex.FindStringSubmatch(r.SeatClass); match != nil {
+			details.Cabin = transform.Title(match[1])
+		}
+	}
+
+	if len(segments) == 0 {
+		return model.Itinerary{}, false
+	}
+
+	itinerary := Finalize(codes, registry)
+	itinerary.Segments = segments
+	itinerary.Passengers = passengers
+	itinerary.Conflicts = conflicts
+	itinerary.Document = &classify.Document{
+		Type:       classify.ETicket,
+		Confidence: schemaConfidence,
+		Travel:     true,
+	}
+	if receipt.Total > 0 || len(receipt.TicketNumbers) > 0 {
+		if c, found := currency.Get(receipt.Currency); found {
+			receipt.Total = c.Round(receipt.Total)
+		}
+		itinerary.Receipt = &receipt
+	}
+
+	return itinerary, true
+}
+
+// reservationPrice adds ticket and price of reservation to receipt. Markup repeats price of a ticket on
+// reservations of all its flights, so price is counted once per ticket, or per passenger if there is no ticket.
+func reservationPrice(receipt *model.Receipt, r schemaorg.Reservation, priced map[string]bool) {
+	if receipt.Reservation == "" {
+		receipt.Reservation = r.ReservationNumber
+	}
+
+	number := strings.ReplaceAll(strings.ReplaceAll(r.TicketNumber, "-", ""), " ", "")
+	if number != "" && !transform.InSlice(number, receipt.TicketNumbers) {
+		receipt.TicketNumbers = append(receipt.TicketNumbers, number)
+	}
+
+	if r.TotalPrice == "" {
+		return
+	}
+
+	amount := parseAmount(r.TotalPrice)
+	code := firstOf(r.PriceCurrency, printedCurrency(r.TotalPrice))
+	if amount == 0 || (receipt.Currency != "" && code != "" && code != receipt.Currency) {
+		return
+	}
+
+	key := firstOf(number, strings.ToUpper(r.Passenger), r.ReservationNumber)
+	if priced[key] {
+		return
+	}
+	priced[key] = true
+
+	receipt.Currency = firstOf(receipt.Currency, code)
+	receipt.Total += amount
+}
+
+// reservationSegment reads segment of reserved flight, found is false if either airport is unknown.
+// Airports found by their name are returned as conflicts with code of markup, their segment is left to caller.
+func reservationSegment(flight schemaorg.Flight, registry *airport.Registry) (segment model.Segment, searched []model.Conflict, found bool) {
+	segment = model.Segment{
+		DepartureTerminal: strings.TrimPrefix(strings.ToUpper(flight.DepartureTerminal), "T"),
+		ArrivalTerminal:   strings.TrimPrefix(strings.ToUpper(flight.ArrivalTerminal), "T"),
+		Gate:              strings.ToUpper(flight.DepartureGate),
+		Source:            SourceSchema,
+		Confidence:        schemaConfidence,
+	}
+
+	for _, side := range []struct {
+		field string
+		code  *string
+		a     schemaorg.Airport
+	}{
+		{"departure", &segment.Departure, flight.Departure},
+		{"arrival", &segment.Arrival, flight.Arrival},
+	} {
+		var byName bool
+		if *side.code, byName, found = schemaAirport(side.a, registry); !found {
+			return
+		}
+
+		if byName {
+			searched = append(searched, model.Conflict{
+				Field:          side.field,
+				Value:          *side.code,
+				Source:         SourceAirportName,
+				Rejected:       firstOf(side.a.IATA, side.a.Name),
+				RejectedSource: SourceSchema,
+			})
+		}
+	}
+
+	// flight number is either complete or only the number, with carrier given as airline
+	number := strings.ToUpper(strings.TrimSpace(flight.FlightNumber))
+	if match := schemaFlightRegex.FindStringSubmatch(number); match != nil {
+		carrier := match[1]
+		if carrier == "" {
+			carrier = strings.ToUpper(flight.Airline)
+		}
+		segment.FlightNumber = carrier + strings.TrimLeft(match[2], "0")
+	}
+
+	departure, departed := passTime(flight.DepartureTime)
+	if !departed {
+		return
+	}
+	segment.Date = departure.Format("2006-01-02")
+	segment.DepartureTime = departure.Format("15:04")
+
+	arrival, arrived := passTime(flight.ArrivalTime)
+	if !arrived {
+		return
+	}
+	segment.ArrivalTime = arrival.Format("15:04")
+
+	// times with offsets are exact, local times need time zones of airports
+	if zoneRegex.MatchString(flight.DepartureTime) && zoneRegex.MatchString(flight.ArrivalTime) {
+		segment.BlockMinutes = int(arrival.Sub(departure).Minutes())
+		return
+	}
+
+	from, _ := registry.IATA(segment.Departure)
+	to, _ := registry.IATA(segment.Arrival)
+	segment.BlockMinutes = blockMinutes(departure, departure.Hour()*60+departure.Minute(),
+		arrival.Hour()*60+arrival.Minute(), from.Tz, to.Tz)
+
+	return
+}
+
+// schemaAirport resolves airport of markup to IATA code, by its code or by its name if the code is unknown.
+// byName tells that the code is the best match of a search, not a value of markup. Names are resolved only when
+// they are the exact name of an airport or clearly its best match, a guess would become a bogus segment.
+func schemaAirport(a schemaorg.Airport, registry *airport.Registry) (code string, byName, found bool) {
+	if found, ok := knownAirport(a.IATA, registry); ok {
+		return found.IATA, false, true
+	}
+
+	if a.Name == "" {
+		return "", false, false
+	}
+
+	results := make([]airport.Result, 0, 2)
+	for _, result := range registry.Search(airport.Query{Text: a.Name}) {
+		if _, ok := knownAirport(result.Airport.IATA, registry); ok {
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return "", false, false
+	}
+
+	top := results[0]
+	if strings.EqualFold(transform.Fold(top.Airport.Name), transform.Fold(a.Name)) {
+		return top.Airport.IATA, true, true
+	}
+
+	// every word has to match a whole word of the airport, and no other airport may match as well
+	words := len(strings.Fields(a.Name))
+	if top.Score < airport.ExactScore*words || (len(results) > 1 && results[1].Score == top.Score) {
+		return "", false, false
+	}
+
+	return top.Airport.IATA, true, true
+}
+
+// knownAirport returns airport of a valid IATA code the registry knows
+func knownAirport(code string, registry *airport.Registry) (*airport.Airport, bool) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !iataCodeRegex.MatchString(code) {
+		return nil, false
+	}
+
+	return registry.IATA(code)
+}
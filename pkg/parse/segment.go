@@ -0,0 +1,164 @@
+package parse
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+	"trikliq-airport-finder/pkg/country"
+	"trikliq-airport-finder/pkg/flight"
+	"trikliq-airport-finder/pkg/geo"
+)
+
+// Schedule attaches dates, times and durations found in text to segments, in order of appearance.
+// Times are paired within lines of their segment, a time of one segment never ends up in another.
+// Segments without printed date are timed as of now.
+func Schedule(txt string, itinerary *model.Itinerary, registry *airport.Registry, now time.Time) {
+	var (
+		dates     = extractDates(txt)
+		times     = extractTimes(txt)
+		durations = extractDurations(txt)
+		windows   = segmentWindows(txt, itinerary.Segments)
+	)
+
+	for i := range itinerary.Segments {
+		segment := &itinerary.Segments[i]
+
+		// segments on the same day share a date
+		date := now
+		if len(dates) > 0 {
+			date = dates[len(dates)-1]
+			if i < len(dates) {
+				date = dates[i]
+			}
+			segment.Date = date.Format("2006-01-02")
+		}
+
+		departure, arrival, found := segmentTimes(windows[i], times, i, len(itinerary.Segments))
+		if found {
+			segment.DepartureTime = clock(departure)
+			segment.ArrivalTime = clock(arrival)
+
+			from, _ := registry.IATA(segment.Departure)
+			to, _ := registry.IATA(segment.Arrival)
+			if from != nil && to != nil {
+				segment.BlockMinutes = blockMinutes(date, departure, arrival, from.Tz, to.Tz)
+			}
+		}
+
+		// printed duration is more reliable than difference of printed times
+		if len(durations) == len(itinerary.Segments) {
+			segment.BlockMinutes = durations[i]
+		}
+	}
+}
+
+// segmentWindows returns text of every segment, from its first line up to the first line of the next found segment,
+// empty for segments not found in text
+func segmentWindows(txt string, segments []model.Segment) []string {
+	var (
+		lines   = strings.Split(txt, "\n")
+		anchors = segmentAnchors(lines, segments)
+		windows = make([]string, len(segments))
+	)
+
+	for i, anchor := range anchors {
+		if anchor == -1 {
+			continue
+		}
+
+		end := len(lines)
+		for _, next := range anchors[i+1:] {
+			if next != -1 {
+				end = next
+				break
+			}
+		}
+
+		windows[i] = strings.Join(lines[anchor:end], "\n")
+	}
+
+	return windows
+}
+
+// segmentTimes returns departure and arrival time of segment, the first two times of its window.
+// Times of segments not found in text are taken in order only if text has exactly two times per segment.
+func segmentTimes(window string, times []int, i, segments int) (departure, arrival int, found bool) {
+	if window != "" {
+		inWindow := extractTimes(window)
+		if len(inWindow) < 2 {
+			return
+		}
+
+		return inWindow[0], inWindow[1], true
+	}
+
+	if len(times) != 2*segments {
+		return
+	}
+
+	return times[2*i], times[2*i+1], true
+}
+
+// Enrich classifies every segment and calculates its distance, block time plausibility and CO2 estimate,
+// countries itinerary passes through are listed in order of travel
+func Enrich(itinerary *model.Itinerary, registry *airport.Registry) {
+	itinerary.Countries = make([]*country.Country, 0)
+
+	for i := range itinerary.Segments {
+		segment := &itinerary.Segments[i]
+		segment.Plausibility = flight.Check(0, 0)
+
+		departure, found := registry.IATA(segment.Departure)
+		if !found {
+			continue
+		}
+		arrival, found := registry.IATA(segment.Arrival)
+		if !found {
+			continue
+		}
+
+		segment.DepartureCountry = departure.Country
+		segment.ArrivalCountry = arrival.Country
+		segment.Classification = country.Classify(departure.Country, arrival.Country)
+
+		for _, code := range []string{departure.Country, arrival.Country} {
+			c, found := country.Get(code)
+			if found && !inCountries(itinerary.Countries, c) {
+				itinerary.Countries = append(itinerary.Countries, c)
+			}
+		}
+
+		lat1, lon1, ok := departure.Coordinates()
+		if !ok {
+			segment.Plausibility.Reason = "no coordinates of " + departure.IATA + ", distance and CO2 are not calculated"
+			continue
+		}
+		lat2, lon2, ok := arrival.Coordinates()
+		if !ok {
+			segment.Plausibility.Reason = "no coordinates of " + arrival.IATA + ", distance and CO2 are not calculated"
+			continue
+		}
+
+		distance := geo.Haversine(lat1, lon1, lat2, lon2)
+		segment.DistanceKm = math.Round(distance*10) / 10
+		segment.DistanceMi = math.Round(distance*geo.KmToMiles*10) / 10
+		segment.Plausibility = flight.Check(distance, segment.BlockMinutes)
+
+		emission := flight.CO2(distance)
+		segment.CO2 = &emission
+	}
+}
+
+// inCountries checks if country is already listed
+func inCountries(countries []*country.Country, c *country.Country) bool {
+	for _, listed := range countries {
+		if listed == c {
+			return true
+		}
+	}
+
+	return false
+}
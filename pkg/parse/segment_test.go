@@ -0,0 +1,44 @@
+package parse
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+)
+
+func TestScheduleWithoutDate(t *testing.T) {
+	registry, err := airport.Load(filepath.Join("..", "..", airport.DataPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	txt := "LHR London Heathrow 10:00\nJFK New York 13:00\n"
+
+	// clocks of London and New York change on different days, block time depends on the date
+	cases := []struct {
+		now  time.Time
+		want int
+	}{
+		{time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC), 480},
+		{time.Date(2024, 3, 20, 0, 0, 0, 0, time.UTC), 420},
+	}
+
+	for _, c := range cases {
+		itinerary := model.Itinerary{Segments: []model.Segment{{Departure: "LHR", Arrival: "JFK"}}}
+		Schedule(txt, &itinerary, registry, c.now)
+
+		segment := itinerary.Segments[0]
+		if segment.DepartureTime != "10:00" || segment.ArrivalTime != "13:00" {
+			t.Fatalf("got times %q and %q", segment.DepartureTime, segment.ArrivalTime)
+		}
+		if segment.BlockMinutes != c.want {
+			t.Errorf("%s: got %d block minutes, want %d", c.now.Format("2006-01-02"), segment.BlockMinutes, c.want)
+		}
+		if segment.Date != "" {
+			t.Errorf("got date %q of segment without printed date", segment.Date)
+		}
+	}
+}
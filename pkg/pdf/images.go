@@ -0,0 +1,347 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"trikliq-airport-finder/pkg/crypto"
+	"trikliq-airport-finder/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// MaxImagePixels skips images too big to be decoded in memory
+	MaxImagePixels = 25_000_000
+
+	// renderDPI is enough for barcodes drawn as vector graphics
+	renderDPI = 200
+	// renderPages are rendered at most, boarding passes print their barcode on the first pages
+	renderPages = 2
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	streamRegex    = regexp.MustCompile(`>>\s*stream\r?\n`)
+	objRegex       = regexp.MustCompile(`\d+\s+\d+\s+obj\b`)
+	widthRegex     = regexp.MustCompile(`/Width\s+(\d+)`)
+	heightRegex    = regexp.MustCompile(`/Height\s+(\d+)`)
+	bitsRegex      = regexp.MustCompile(`/BitsPerComponent\s+(\d+)`)
+	lengthRegex    = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+	predictorRegex = regexp.MustCompile(`/Predictor\s+(\d+)`)
+	decodeRegex    = regexp.MustCompile(`/Decode\s*\[\s*1(\.0)?\s+0(\.0)?`)
+
+	errUnsupported = errors.New("unsupported image format")
+	// ErrImageTooLarge is returned for images of more than MaxImagePixels, they are not decoded at all
+	ErrImageTooLarge = errors.New("image is too large")
+)
+
+// DecodeImage decodes image of any registered format. Dimensions are read from its header first, a small file
+// may claim dimensions which take gigabytes to decode.
+func DecodeImage(raw []byte) (image.Image, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > MaxImagePixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(raw))
+	return img, err
+}
+
+// Images returns images embedded in pdf. pdfimages is used when it is installed,
+// otherwise images are read from the pdf directly.
+func Images(file []byte) []image.Image {
+	images, err := extract(file, "pdfimages", "-png")
+	if err == nil && len(images) > 0 {
+		return images
+	}
+
+	return embedded(file)
+}
+
+// Render returns the first pages of pdf as images, barcodes are often drawn as vector graphics and not embedded as images
+func Render(file []byte) ([]image.Image, error) {
+	return extract(file, "pdftoppm", "-png", "-r", strconv.Itoa(renderDPI), "-f", "1", "-l", strconv.Itoa(renderPages))
+}
+
+// extract runs poppler tool which writes png files named after prefix
+func extract(file []byte, tool string, options ...string) (images []image.Image, err error) {
+	uuid, _ := crypto.UUID()
+	dir := filepath.Join("/tmp", uuid+"-images")
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	if err = os.WriteFile(filepath.Join(dir, "input.pdf"), file, 0644); err != nil {
+		return
+	}
+
+	args := append(options, "input.pdf", "image")
+	_, stderr, err := RunCommand(dir, tool, 20*time.Second, nil, args...)
+	if err != nil {
+		logger.Log.Debug("failed to extract images",
+			zap.String("tool", tool),
+			zap.Error(err),
+			zap.String("stderr", stderr),
+		)
+		return
+	}
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "image-*.png"))
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		img, err := DecodeImage(raw)
+		if err != nil {
+			logger.Log.Debug("skipping extracted image",
+				zap.String("tool", tool),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		images = append(images, img)
+	}
+
+	return
+}
+
+// embedded reads image XObjects compressed with DCT or Flate, other filters are skipped
+func embedded(file []byte) []image.Image {
+	images := make([]image.Image, 0)
+
+	for _, match := range streamRegex.FindAllIndex(file, -1) {
+		// dictionary of the stream starts after the nearest object header
+		start := 0
+		if headers := objRegex.FindAllIndex(file[max(0, match[0]-4096):match[0]], -1); len(headers) > 0 {
+			start = max(0, match[0]-4096) + headers[len(headers)-1][1]
+		}
+		dict := file[start:match[0]]
+
+		if !bytes.Contains(dict, []byte("/Image")) {
+			continue
+		}
+
+		data := streamData(file, dict, match[1])
+
+		img, err := decodeImage(dict, data)
+		if err != nil {
+			logger.Log.Debug("skipping embedded image",
+				zap.Error(err),
+			)
+			continue
+		}
+
+		images = append(images, img)
+	}
+
+	return images
+}
+
+// streamData returns content of stream, length is taken from dictionary unless it is an indirect object
+func streamData(file, dict []byte, start int) []byte {
+	if match := lengthRegex.FindSubmatch(dict); match != nil && len(match[2]) == 0 {
+		length, _ := strconv.Atoi(string(match[1]))
+		if start+length <= len(file) {
+			return file[start : start+length]
+		}
+	}
+
+	end := bytes.Index(file[start:], []byte("endstream"))
+	if end == -1 {
+		return file[start:]
+	}
+
+	return bytes.TrimRight(file[start:start+end], "\r\n")
+}
+
+func decodeImage(dict, data []byte) (image.Image, error) {
+	switch {
+	case bytes.Contains(dict, []byte("/DCTDecode")):
+		return DecodeImage(data)
+
+	case bytes.Contains(dict, []byte("/FlateDecode")):
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		raw, err := io.ReadAll(reader)
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+
+		return rawImage(dict, raw)
+	}
+
+	return nil, errUnsupported
+}
+
+// rawImage builds image from uncompressed samples, number of color components is inferred from data length
+func rawImage(dict, raw []byte) (image.Image, error) {
+	width, height, bits := number(widthRegex, dict), number(heightRegex, dict), number(bitsRegex, dict)
+
+	mask := bytes.Contains(dict, []byte("/ImageMask true"))
+	if mask {
+		bits = 1
+	}
+
+	if width <= 0 || height <= 0 || width*height > MaxImagePixels || (bits != 1 && bits != 8) {
+		return nil, errUnsupported
+	}
+
+	predicted := number(predictorRegex, dict) >= 10
+
+	components := 0
+	for _, c := range []int{1, 3, 4} {
+		stride := (width*c*bits + 7) / 8
+		if predicted {
+			stride++
+		}
+
+		if len(raw) == stride*height {
+			components = c
+			break
+		}
+	}
+	if components == 0 {
+		return nil, errUnsupported
+	}
+
+	stride := (width*components*bits + 7) / 8
+	if predicted {
+		raw = unpredict(raw, stride, (components*bits+7)/8, height)
+	}
+
+	// zero is black for both gray images and image masks, unless decode array swaps it
+	invert := decodeRegex.Match(dict)
+
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		row := raw[y*stride : (y+1)*stride]
+
+		for x := 0; x < width; x++ {
+			var l uint8
+
+			switch {
+			case bits == 1:
+				if row[x/8]>>(7-uint(x%8))&1 == 1 {
+					l = 255
+				}
+			case components == 1:
+				l = row[x]
+			case components == 3:
+				r, g, b := row[3*x], row[3*x+1], row[3*x+2]
+				l = color.GrayModel.Convert(color.RGBA{r, g, b, 255}).(color.Gray).Y
+			case components == 4:
+				c, m, yl, k := row[4*x], row[4*x+1], row[4*x+2], row[4*x+3]
+				l = color.GrayModel.Convert(color.CMYK{C: c, M: m, Y: yl, K: k}).(color.Gray).Y
+			}
+
+			if invert {
+				l = 255 - l
+			}
+			img.Pix[y*img.Stride+x] = l
+		}
+	}
+
+	return img, nil
+}
+
+// unpredict reverts PNG predictors, every row starts with its filter type
+func unpredict(raw []byte, stride, bpp, height int) []byte {
+	result := make([]byte, stride*height)
+	previous := make([]byte, stride)
+
+	for y := 0; y < height; y++ {
+		filter := raw[y*(stride+1)]
+		row := raw[y*(stride+1)+1 : (y+1)*(stride+1)]
+		out := result[y*stride : (y+1)*stride]
+
+		for i := range row {
+			var left, upLeft byte
+			if i >= bpp {
+				left, upLeft = out[i-bpp], previous[i-bpp]
+			}
+			up := previous[i]
+
+			switch filter {
+			case 1:
+				out[i] = row[i] + left
+			case 2:
+				out[i] = row[i] + up
+			case 3:
+				out[i] = row[i] + byte((int(left)+int(up))/2)
+			case 4:
+				out[i] = row[i] + paeth(left, up, upLeft)
+			default:
+				out[i] = row[i]
+			}
+		}
+
+		previous = out
+	}
+
+	return result
+}
+
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := abs(p-int(a)), abs(p-int(b)), abs(p-int(c))
+
+	switch {
+	case pa <= pb && pa <= pc:
+		return a
+	case pb <= pc:
+		return b
+	}
+
+	return c
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func number(regex *regexp.Regexp, dict []byte) int {
+	match := regex.FindSubmatch(dict)
+	if match == nil {
+		return 0
+	}
+
+	n, _ := strconv.Atoi(string(match[1]))
+	return n
+}
@@ -0,0 +1,54 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// header returns PNG of a single pixel whose header claims width and height
+func header(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	raw := b.Bytes()
+
+	// IHDR follows the 8 byte signature, its data follows length and type of the chunk
+	ihdr := raw[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:4], width)
+	binary.BigEndian.PutUint32(ihdr[4:8], height)
+	binary.BigEndian.PutUint32(raw[8+8+13:], crc32.ChecksumIEEE(raw[8+4:8+8+13]))
+
+	return raw
+}
+
+func TestDecodeImage(t *testing.T) {
+	img, err := DecodeImage(header(t, 1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 1 || img.Bounds().Dy() != 1 {
+		t.Errorf("got bounds %v", img.Bounds())
+	}
+}
+
+func TestDecodeImageTooLarge(t *testing.T) {
+	// a few dozen bytes claiming 2.5 gigapixels
+	raw := header(t, 50_000, 50_000)
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil || config.Width != 50_000 {
+		t.Fatalf("header was not patched: %+v, %v", config, err)
+	}
+
+	if _, err := DecodeImage(raw); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("got %v, want %v", err, ErrImageTooLarge)
+	}
+}
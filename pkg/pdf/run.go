@@ -2,11 +2,15 @@ package pdf
 
 import (
 	"bytes"
+	"errors"
 	"os"
 	"os/exec"
 	"time"
 )
 
+// ErrTimeout is returned when command runs longer than allowed
+var ErrTimeout = errors.New("command timed out")
+
 // RunCommand performs run of desired command with timeout option
 func RunCommand(dir, execFile string, maxRuntime time.Duration, environment []string, args ...string) (stdout, stderr string, err error) {
 
@@ -26,9 +30,13 @@ func RunCommand(dir, execFile string, maxRuntime time.Duration, environment []st
 		cmd.Dir = dir
 	}
 
-	done := make(chan error)
+	// missing executable must not leave process to be killed
+	if err = cmd.Start(); err != nil {
+		return
+	}
+
+	done := make(chan error, 1)
 	go func() {
-		cmd.Start()
 		done <- cmd.Wait()
 	}()
 	defer func() {
@@ -38,6 +46,7 @@ func RunCommand(dir, execFile string, maxRuntime time.Duration, environment []st
 	select {
 	case <-time.After(maxRuntime):
 		cmd.Process.Kill()
+		err = ErrTimeout
 		return
 
 	case err = <-done:
@@ -1,11 +1,17 @@
 package pdf
 
+import "sort"
+
 // Declaring trie_Node  for creating node in a trie
 type trie_Node struct {
 	//assigning limit of 26 for child nodes
 	childrens [26]*trie_Node
 	//declaring a bool variable to check the word end.
 	wordEnds bool
+	//values attached to words ending in this node
+	values []int
+	//best values of the whole subtree, filled by Rank
+	top []int
 }
 
 // Initializing the root of the trie
@@ -22,6 +28,21 @@ func TrieData() *Trie {
 
 // Passing words to trie
 func (t *Trie) Insert(word string) {
+	t.insert(word)
+}
+
+// InsertValue passes word to trie and attaches value to it
+func (t *Trie) InsertValue(word string, value int) {
+	current := t.insert(word)
+	for _, v := range current.values {
+		if v == value {
+			return
+		}
+	}
+	current.values = append(current.values, value)
+}
+
+func (t *Trie) insert(word string) *trie_Node {
 	current := t.root
 	for _, wr := range word {
 
@@ -36,6 +57,8 @@ func (t *Trie) Insert(word string) {
 		current = current.childrens[index]
 	}
 	current.wordEnds = true
+
+	return current
 }
 
 // Initializing the search for word in node
@@ -62,3 +85,67 @@ func (t *Trie) Search(word string) int {
 
 	return 0
 }
+
+// node walks to the node of prefix, characters outside of a-z are skipped same as in Insert
+func (t *Trie) node(prefix string) *trie_Node {
+	current := t.root
+	for _, wr := range prefix {
+		if wr < 97 || wr > 122 {
+			continue
+		}
+
+		current = current.childrens[wr-'a']
+		if current == nil {
+			return nil
+		}
+	}
+
+	return current
+}
+
+// Rank precomputes k best values of every subtree, better reports if value a ranks before b
+func (t *Trie) Rank(k int, better func(a, b int) bool) {
+	rank(t.root, k, better)
+}
+
+func rank(current *trie_Node, k int, better func(a, b int) bool) []int {
+	candidates := make([]int, 0, len(current.values)+k)
+	candidates = append(candidates, current.values...)
+
+	for _, child := range current.childrens {
+		if child == nil {
+			continue
+		}
+		candidates = append(candidates, rank(child, k, better)...)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return better(candidates[i], candidates[j])
+	})
+
+	top := make([]int, 0, k)
+	seen := make(map[int]bool, len(candidates))
+	for _, candidate := range candidates {
+		if len(top) == k {
+			break
+		}
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+		top = append(top, candidate)
+	}
+
+	current.top = top
+	return top
+}
+
+// Top returns values precomputed by Rank for words starting with prefix, best first
+func (t *Trie) Top(prefix string) []int {
+	current := t.node(prefix)
+	if current == nil {
+		return nil
+	}
+
+	return current.top
+}
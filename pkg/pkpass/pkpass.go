@@ -0,0 +1,296 @@
+// Package pkpass reads Apple Wallet passes
+package pkpass
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	PassFile      = "pass.json"
+	ManifestFile  = "manifest.json"
+	SignatureFile = "signature"
+
+	// maxFileSize of a single file in pass, passes are mostly a few images
+	maxFileSize = 10 << 20
+	// maxFiles in archive, images in three resolutions and a localization per language are well below it
+	maxFiles = 256
+	// maxTotalSize of all files in archive
+	maxTotalSize = 50 << 20
+)
+
+var (
+	// ErrNotPass is returned for archives without pass.json
+	ErrNotPass = errors.New("pkpass: pass.json not found")
+)
+
+// ManifestError lists files whose hashes do not match manifest
+type ManifestError struct {
+	Files []string
+}
+
+func (e *ManifestError) Error() string {
+	return fmt.Sprintf("pkpass: manifest does not match %s", strings.Join(e.Files, ", "))
+}
+
+// Pass is content of pass.json
+type Pass struct {
+	FormatVersion      int       `json:"formatVersion"`
+	PassTypeIdentifier string    `json:"passTypeIdentifier"`
+	SerialNumber       string    `json:"serialNumber"`
+	TeamIdentifier     string    `json:"teamIdentifier"`
+	OrganizationName   string    `json:"organizationName"`
+	Description        string    `json:"description"`
+	RelevantDate       string    `json:"relevantDate,omitempty"`
+	Barcode            *Barcode  `json:"barcode,omitempty"`
+	Barcodes           []Barcode `json:"barcodes,omitempty"`
+	BoardingPass       *Fields   `json:"boardingPass,omitempty"`
+	Signed             bool      `json:"signed"`
+}
+
+// Barcode of pass, message of boarding passes is usually BCBP
+type Barcode struct {
+	Format          string `json:"format"`
+	Message         string `json:"message"`
+	MessageEncoding string `json:"messageEncoding"`
+	AltText         string `json:"altText,omitempty"`
+}
+
+// TransitAir is transit type of flights, passes of trains and buses use other types
+const TransitAir = "PKTransitTypeAir"
+
+// Fields is the boardingPass structure
+type Fields struct {
+	TransitType     string  `json:"transitType"`
+	HeaderFields    []Field `json:"headerFields,omitempty"`
+	PrimaryFields   []Field `json:"primaryFields,omitempty"`
+	SecondaryFields []Field `json:"secondaryFields,omitempty"`
+	AuxiliaryFields []Field `json:"auxiliaryFields,omitempty"`
+	BackFields      []Field `json:"backFields,omitempty"`
+}
+
+// Field is a labeled value printed on pass
+type Field struct {
+	Key   string          `json:"key"`
+	Label string          `json:"label,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// String returns value of field as text, values may be strings, numbers or dates
+func (f Field) String() string {
+	var text string
+	if err := json.Unmarshal(f.Value, &text); err == nil {
+		return text
+	}
+
+	var number float64
+	if err := json.Unmarshal(f.Value, &number); err == nil {
+		return strconv.FormatFloat(number, 'f', -1, 64)
+	}
+
+	return strings.Trim(string(f.Value), `"`)
+}
+
+// All returns every field of boarding pass, front of pass first
+func (f *Fields) All() []Field {
+	fields := make([]Field, 0)
+	for _, group := range [][]Field{f.HeaderFields, f.PrimaryFields, f.SecondaryFields, f.AuxiliaryFields, f.BackFields} {
+		fields = append(fields, group...)
+	}
+
+	return fields
+}
+
+// Messages returns messages of every barcode of pass, legacy barcode included
+func (p *Pass) Messages() []string {
+	messages := make([]string, 0)
+
+	barcodes := append([]Barcode(nil), p.Barcodes...)
+	if p.Barcode != nil {
+		barcodes = append(barcodes, *p.Barcode)
+	}
+
+	for _, b := range barcodes {
+		if b.Message != "" && !contains(messages, b.Message) {
+			messages = append(messages, b.Message)
+		}
+	}
+
+	return messages
+}
+
+// IsArchive reports if raw is a pass or a bundle of passes
+func IsArchive(raw []byte) bool {
+	archive, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return false
+	}
+
+	for _, f := range archive.File {
+		if f.Name == PassFile || strings.HasSuffix(strings.ToLower(f.Name), ".pkpass") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Read unzips passes, verifies hashes of their manifests and reads pass.json.
+// Bundles (.pkpasses) hold several passes, a single pass is returned as one element.
+func Read(raw []byte) ([]*Pass, error) {
+	archive, err := zip.NewReader(bytes.NewReader(raw), int64(len(raw)))
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := unzip(archive)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, found := files[PassFile]; found {
+		pass, err := read(files)
+		if err != nil {
+			return nil, err
+		}
+		return []*Pass{pass}, nil
+	}
+
+	names := make([]string, 0)
+	for name := range files {
+		if strings.HasSuffix(strings.ToLower(name), ".pkpass") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return nil, ErrNotPass
+	}
+
+	passes := make([]*Pass, 0, len(names))
+	for _, name := range names {
+		inner, err := Read(files[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		passes = append(passes, inner...)
+	}
+
+	return passes, nil
+}
+
+func unzip(archive *zip.Reader) (map[string][]byte, error) {
+	if len(archive.File) > maxFiles {
+		return nil, fmt.Errorf("pkpass: archive has %d files, at most %d are read", len(archive.File), maxFiles)
+	}
+
+	var (
+		files = make(map[string][]byte, len(archive.File))
+		total = 0
+	)
+
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		if f.UncompressedSize64 > maxFileSize {
+			return nil, fmt.Errorf("pkpass: %s is too big", f.Name)
+		}
+
+		reader, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := io.ReadAll(io.LimitReader(reader, maxFileSize))
+		reader.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		// declared sizes may lie, so the limit is checked on content
+		if total += len(content); total > maxTotalSize {
+			return nil, errors.New("pkpass: archive is too big")
+		}
+
+		files[f.Name] = content
+	}
+
+	return files, nil
+}
+
+func read(files map[string][]byte) (*Pass, error) {
+	if err := verify(files); err != nil {
+		return nil, err
+	}
+
+	pass := &Pass{}
+	if err := json.Unmarshal(files[PassFile], pass); err != nil {
+		return nil, fmt.Errorf("pkpass: %w", err)
+	}
+
+	// signature is a PKCS #7 detached signature of manifest, checking it needs Apple's certificate chain
+	_, pass.Signed = files[SignatureFile]
+
+	return pass, nil
+}
+
+// verify checks SHA-1 hash of every file against manifest, files missing in manifest are not trusted either
+func verify(files map[string][]byte) error {
+	raw, found := files[ManifestFile]
+	if !found {
+		return &ManifestError{Files: []string{ManifestFile}}
+	}
+
+	manifest := make(map[string]string)
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return fmt.Errorf("pkpass: %w", err)
+	}
+
+	mismatched := make([]string, 0)
+
+	for name, content := range files {
+		if name == ManifestFile || name == SignatureFile {
+			continue
+		}
+
+		sum := sha1.Sum(content)
+		if !strings.EqualFold(manifest[name], hex.EncodeToString(sum[:])) {
+			mismatched = append(mismatched, name)
+		}
+	}
+
+	for name := range manifest {
+		if _, found := files[name]; !found {
+			mismatched = append(mismatched, name)
+		}
+	}
+
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		return &ManifestError{Files: mismatched}
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
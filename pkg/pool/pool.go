@@ -0,0 +1,193 @@
+// Package pool bounds work shared by every request of the process, e.g. parsing which runs subprocesses.
+// Work beyond the queue is refused rather than piled up.
+package pool
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// weight of the last task in average duration of tasks
+	weight = 0.2
+
+	minRetryAfter = time.Second
+	maxRetryAfter = time.Minute
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	// ErrSaturated is returned when the queue has no room for tasks
+	ErrSaturated = errors.New("too many files are being read, try again later")
+	// ErrTooLarge is returned when tasks would not fit into the queue even if it was empty
+	ErrTooLarge = errors.New("too many files to read at once")
+)
+
+// Pool runs tasks on a fixed number of workers, tasks waiting for a worker are queued up to a depth
+type Pool struct {
+	// slots are held by queued and running tasks, workers by running tasks only
+	slots   chan struct{}
+	workers chan struct{}
+
+	mu      sync.Mutex
+	average time.Duration
+}
+
+// New returns pool of size workers and queue of depth tasks
+func New(size, depth int) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	if depth < 0 {
+		depth = 0
+	}
+
+	return &Pool{
+		slots:   make(chan struct{}, size+depth),
+		workers: make(chan struct{}, size),
+	}
+}
+
+//lint:ignore GLOBAL this is okay
+var (
+	pool     *Pool
+	poolOnce sync.Once
+)
+
+// Default returns pool of the process with size and depth of Config
+func Default() *Pool {
+	poolOnce.Do(func() {
+		pool = New(Config.Size, Config.Depth)
+	})
+
+	return pool
+}
+
+// Run runs tasks at once and waits for them. Tasks are refused together with ErrSaturated when the queue
+// has no room for all of them, or with ErrTooLarge when they exceed it. Tasks still waiting for a worker once
+// ctx is done are dropped with its error, panics of tasks are raised again by Run.
+func (p *Pool) Run(ctx context.Context, tasks ...func()) error {
+	if len(tasks) > cap(p.slots) {
+		return ErrTooLarge
+	}
+
+	for i := range tasks {
+		select {
+		case p.slots <- struct{}{}:
+		default:
+			p.release(i)
+			return ErrSaturated
+		}
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		fault any
+		err   error
+	)
+
+	for _, task := range tasks {
+		wg.Add(1)
+		go func(task func()) {
+			defer wg.Done()
+
+			r, e := p.run(ctx, task)
+			mu.Lock()
+			if fault == nil {
+				fault = r
+			}
+			if err == nil {
+				err = e
+			}
+			mu.Unlock()
+		}(task)
+	}
+
+	wg.Wait()
+
+	if fault != nil {
+		panic(fault)
+	}
+
+	return err
+}
+
+// Do runs task once the queue has room for it and a worker is free and waits for it, or returns error of ctx.
+// Panics of task are raised again by Do.
+func (p *Pool) Do(ctx context.Context, task func()) error {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	r, err := p.run(ctx, task)
+	if r != nil {
+		panic(r)
+	}
+
+	return err
+}
+
+// RetryAfter estimates when tasks queued now are done, from average duration of tasks
+func (p *Pool) RetryAfter() time.Duration {
+	p.mu.Lock()
+	average := p.average
+	p.mu.Unlock()
+
+	// every worker takes a task of the queue per average duration
+	wait := time.Duration(float64(average) * math.Ceil(float64(len(p.slots))/float64(cap(p.workers))))
+	if wait < minRetryAfter {
+		return minRetryAfter
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+
+	return wait
+}
+
+// run runs task holding a slot on a worker and returns its panic, if any. Task is not run if ctx is done
+// before a worker is free.
+func (p *Pool) run(ctx context.Context, task func()) (fault any, err error) {
+	defer p.release(1)
+
+	select {
+	case p.workers <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() {
+		<-p.workers
+	}()
+
+	start := time.Now()
+	defer func() {
+		fault = recover()
+		p.measure(time.Since(start))
+	}()
+
+	task()
+	return nil, nil
+}
+
+func (p *Pool) measure(duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.average == 0 {
+		p.average = duration
+		return
+	}
+	p.average = time.Duration(weight*float64(duration) + (1-weight)*float64(p.average))
+}
+
+func (p *Pool) release(n int) {
+	for i := 0; i < n; i++ {
+		<-p.slots
+	}
+}
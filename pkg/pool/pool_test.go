@@ -0,0 +1,61 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// hold keeps a worker and a slot of pool busy until the returned func is called
+func hold(t *testing.T, p *Pool) func() {
+	t.Helper()
+
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		done    = make(chan struct{})
+	)
+	go func() {
+		defer close(done)
+		p.Do(context.Background(), func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	return func() {
+		close(release)
+		<-done
+	}
+}
+
+func TestRunSaturated(t *testing.T) {
+	p := New(1, 2)
+	release := hold(t, p)
+
+	// a batch fitting into the empty queue is refused while a task holds part of it
+	tasks := []func(){func() {}, func() {}, func() {}}
+	if err := p.Run(context.Background(), tasks...); !errors.Is(err, ErrSaturated) {
+		t.Fatalf("got %v, want %v", err, ErrSaturated)
+	}
+	if n := len(p.slots); n != 1 {
+		t.Errorf("got %d slots held after refusal, want only that of running task", n)
+	}
+
+	if err := p.Run(context.Background(), append(tasks, func() {})...); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("got %v, want %v", err, ErrTooLarge)
+	}
+
+	release()
+
+	// the single worker runs tasks one after another
+	ran := 0
+	counted := func() { ran++ }
+	if err := p.Run(context.Background(), counted, counted, counted); err != nil {
+		t.Fatalf("got %v of batch fitting into idle pool", err)
+	}
+	if ran != 3 {
+		t.Errorf("got %d tasks run, want 3", ran)
+	}
+}
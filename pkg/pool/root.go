@@ -0,0 +1,31 @@
+package pool
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+)
+
+type PoolConfig struct {
+	// Size is how many files are parsed at once by the process, every one may run a subprocess
+	Size int
+	// Depth is how many files may wait for the pool, more are refused
+	Depth int
+}
+
+var Config PoolConfig
+
+func init() {
+
+	Config = PoolConfig{
+		Size:  runtime.NumCPU(),
+		Depth: 32,
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("PARSE_WORKERS")); err == nil && size > 0 {
+		Config.Size = size
+	}
+	if depth, err := strconv.Atoi(os.Getenv("PARSE_QUEUE_DEPTH")); err == nil && depth >= 0 {
+		Config.Depth = depth
+	}
+}
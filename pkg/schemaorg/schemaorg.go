@@ -0,0 +1,263 @@
+// Package schemaorg reads schema.org flight reservations which airlines embed in confirmation emails
+package schemaorg
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"trikliq-airport-finder/pkg/html"
+)
+
+// statuses of reservation, schema.org also allows them as full URLs
+const (
+	StatusConfirmed = "ReservationConfirmed"
+	StatusCancelled = "ReservationCancelled"
+	StatusPending   = "ReservationPending"
+	StatusHold      = "ReservationHold"
+)
+
+// Reservation is a FlightReservation, a reservation of one passenger on one flight
+type Reservation struct {
+	ReservationNumber string `json:"reservationNumber,omitempty"`
+	Status            string `json:"reservationStatus,omitempty"`
+	Passenger         string `json:"underName,omitempty"`
+	TicketNumber      string `json:"ticketNumber,omitempty"`
+	TotalPrice        string `json:"totalPrice,omitempty"`
+	PriceCurrency     string `json:"priceCurrency,omitempty"`
+	Seat              string `json:"airplaneSeat,omitempty"`
+	SeatClass         string `json:"airplaneSeatClass,omitempty"`
+	Flight            Flight `json:"reservationFor"`
+}
+
+// Flight is the reserved flight
+type Flight struct {
+	FlightNumber      string  `json:"flightNumber,omitempty"`
+	Airline           string  `json:"airline,omitempty"`
+	AirlineName       string  `json:"airlineName,omitempty"`
+	Departure         Airport `json:"departureAirport"`
+	Arrival           Airport `json:"arrivalAirport"`
+	DepartureTime     string  `json:"departureTime,omitempty"`
+	ArrivalTime       string  `json:"arrivalTime,omitempty"`
+	DepartureGate     string  `json:"departureGate,omitempty"`
+	DepartureTerminal string  `json:"departureTerminal,omitempty"`
+	ArrivalTerminal   string  `json:"arrivalTerminal,omitempty"`
+}
+
+// Airport is departure or arrival airport, either of its fields may be missing
+type Airport struct {
+	IATA string `json:"iataCode,omitempty"`
+	Name string `json:"name,omitempty"`
+}
+
+// Cancelled reports if reservation was cancelled
+func (r Reservation) Cancelled() bool {
+	return r.Status == StatusCancelled
+}
+
+// Find returns flight reservations of HTML document, both JSON-LD and microdata are read.
+// Malformed JSON-LD scripts are skipped, mailers often break them.
+func Find(raw []byte) ([]Reservation, error) {
+	things := make([]any, 0)
+
+	scripts, err := html.JSONLD(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, script := range scripts {
+		var thing any
+		if json.Unmarshal([]byte(script), &thing) == nil {
+			things = append(things, thing)
+		}
+	}
+
+	items, err := html.Microdata(raw)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		things = append(things, item)
+	}
+
+	reservations := make([]Reservation, 0)
+	for _, thing := range things {
+		collect(thing, &reservations)
+	}
+
+	return reservations, nil
+}
+
+// collect adds every FlightReservation found in thing, reservations may be nested in graphs, lists and orders
+func collect(thing any, reservations *[]Reservation) {
+	switch value := thing.(type) {
+	case []any:
+		for _, v := range value {
+			collect(v, reservations)
+		}
+
+	case map[string]any:
+		if isType(value, "FlightReservation") {
+			*reservations = append(*reservations, reservation(value))
+			return
+		}
+
+		// keys are sorted, so reservations keep the same order between reads
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			if !strings.HasPrefix(key, "@") || key == "@graph" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			collect(value[key], reservations)
+		}
+	}
+}
+
+func reservation(thing map[string]any) Reservation {
+	r := Reservation{
+		ReservationNumber: text(thing["reservationNumber"]),
+		Status:            enumeration(text(thing["reservationStatus"])),
+		Passenger:         name(thing["underName"]),
+		Seat:              text(thing["airplaneSeat"]),
+		SeatClass:         name(thing["airplaneSeatClass"]),
+		TicketNumber:      text(thing["ticketNumber"]),
+	}
+	r.TotalPrice, r.PriceCurrency = price(thing)
+
+	if ticket := object(thing["reservedTicket"]); ticket != nil {
+		if r.TicketNumber == "" {
+			r.TicketNumber = text(ticket["ticketNumber"])
+		}
+		if seat := object(ticket["ticketedSeat"]); seat != nil && r.Seat == "" {
+			r.Seat = text(seat["seatNumber"])
+		}
+		if r.TotalPrice == "" {
+			r.TotalPrice, r.PriceCurrency = price(ticket)
+		}
+	}
+
+	flight := object(thing["reservationFor"])
+	if flight == nil {
+		return r
+	}
+
+	r.Flight = Flight{
+		FlightNumber:      text(flight["flightNumber"]),
+		DepartureTime:     text(flight["departureTime"]),
+		ArrivalTime:       text(flight["arrivalTime"]),
+		DepartureGate:     text(flight["departureGate"]),
+		DepartureTerminal: text(flight["departureTerminal"]),
+		ArrivalTerminal:   text(flight["arrivalTerminal"]),
+		Departure:         airport(flight["departureAirport"]),
+		Arrival:           airport(flight["arrivalAirport"]),
+	}
+
+	// airline is an Organization with iataCode, or just its code
+	if airline := object(flight["airline"]); airline != nil {
+		r.Flight.Airline = text(airline["iataCode"])
+		r.Flight.AirlineName = text(airline["name"])
+	} else {
+		r.Flight.Airline = text(flight["airline"])
+	}
+
+	return r
+}
+
+// price reads totalPrice of reservation or ticket, price is a number, a text or a PriceSpecification
+func price(thing map[string]any) (amount, currency string) {
+	currency = strings.ToUpper(text(thing["priceCurrency"]))
+
+	specification := object(thing["totalPrice"])
+	if specification == nil || specification["@value"] != nil {
+		return text(thing["totalPrice"]), currency
+	}
+
+	if c := text(specification["priceCurrency"]); c != "" {
+		currency = strings.ToUpper(c)
+	}
+
+	return text(specification["price"]), currency
+}
+
+// airport reads Airport, or its code given as plain text
+func airport(value any) Airport {
+	thing := object(value)
+	if thing == nil {
+		return Airport{IATA: text(value)}
+	}
+
+	return Airport{
+		IATA: text(thing["iataCode"]),
+		Name: text(thing["name"]),
+	}
+}
+
+// isType reports if thing has type, types are compared without schema.org prefix
+func isType(thing map[string]any, name string) bool {
+	types, ok := thing["@type"].([]any)
+	if !ok {
+		types = []any{thing["@type"]}
+	}
+
+	for _, t := range types {
+		if enumeration(text(t)) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// enumeration strips schema.org prefix of types and enumeration members, e.g. http://schema.org/ReservationConfirmed
+func enumeration(value string) string {
+	if i := strings.LastIndexAny(value, "/#"); i != -1 {
+		return value[i+1:]
+	}
+
+	return value
+}
+
+// object returns value as object, the first one if it is a list
+func object(value any) map[string]any {
+	switch v := value.(type) {
+	case map[string]any:
+		return v
+	case []any:
+		if len(v) > 0 {
+			return object(v[0])
+		}
+	}
+
+	return nil
+}
+
+// name returns name of Thing, or the text itself if it is not an object
+func name(value any) string {
+	if thing := object(value); thing != nil {
+		return text(thing["name"])
+	}
+
+	return text(value)
+}
+
+// text returns value as trimmed string, numbers are formatted and values of JSON-LD value objects are unwrapped
+func text(value any) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []any:
+		if len(v) > 0 {
+			return text(v[0])
+		}
+	case map[string]any:
+		return text(v["@value"])
+	}
+
+	return ""
+}
@@ -0,0 +1,33 @@
+package transform
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	textTransform "golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// letters which have no decomposed form
+var foldReplacer = strings.NewReplacer(
+	"ø", "o", "Ø", "O",
+	"ł", "l", "Ł", "L",
+	"đ", "d", "Đ", "D",
+	"æ", "ae", "Æ", "AE",
+	"œ", "oe", "Œ", "OE",
+	"ß", "ss",
+	"ı", "i",
+)
+
+// Fold lowercases text and strips diacritics, so "São Paulo" and "sao paulo" compare equal
+func Fold(text string) string {
+	folder := textTransform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+	folded, _, err := textTransform.String(folder, text)
+	if err != nil {
+		folded = text
+	}
+
+	return strings.ToLower(foldReplacer.Replace(folded))
+}
@@ -0,0 +1,12 @@
+package transform
+
+import (
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// Title capitalizes every word of text and lowercases the rest, "PREMIUM ECONOMY" becomes "Premium Economy"
+func Title(text string) string {
+	// casers keep state, so every call gets its own
+	return cases.Title(language.Und).String(text)
+}
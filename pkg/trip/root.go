@@ -0,0 +1,25 @@
+package trip
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Connection window, ground time outside of it starts a new journey
+var (
+	MinConnection = 30 * time.Minute
+	MaxConnection = 24 * time.Hour
+)
+
+func init() {
+	minutes, err := strconv.Atoi(os.Getenv("CONNECTION_MIN_MINUTES"))
+	if err == nil && minutes >= 0 {
+		MinConnection = time.Duration(minutes) * time.Minute
+	}
+
+	minutes, err = strconv.Atoi(os.Getenv("CONNECTION_MAX_MINUTES"))
+	if err == nil && minutes > 0 {
+		MaxConnection = time.Duration(minutes) * time.Minute
+	}
+}
@@ -0,0 +1,215 @@
+package trip
+
+import (
+	"time"
+
+	"trikliq-airport-finder/internal/model"
+	"trikliq-airport-finder/pkg/airport"
+)
+
+const (
+	OneWay    = "one-way"
+	RoundTrip = "round-trip"
+	OpenJaw   = "open-jaw"
+	MultiCity = "multi-city"
+)
+
+// schedule is a segment placed in time, zero times mean the segment has no date or time
+type schedule struct {
+	departure time.Time
+	arrival   time.Time
+}
+
+// Assemble groups segments into journeys joined by connections and classifies the trip
+func Assemble(itinerary *model.Itinerary, registry *airport.Registry) {
+	segments := itinerary.Segments
+	if len(segments) == 0 {
+		return
+	}
+
+	schedules := make([]schedule, len(segments))
+	for i := range segments {
+		schedules[i] = place(&segments[i], registry)
+	}
+
+	journeys := make([]model.Journey, 0)
+	current := model.Journey{
+		Origin:   segments[0].Departure,
+		Segments: []int{0},
+	}
+
+	for i := 1; i < len(segments); i++ {
+		connection, connected := connect(current, segments[i-1], segments[i], schedules[i-1], schedules[i], registry)
+		if connected {
+			current.Connections = append(current.Connections, connection)
+			current.Segments = append(current.Segments, i)
+			continue
+		}
+
+		current.Destination = segments[i-1].Arrival
+		journeys = append(journeys, current)
+
+		current = model.Journey{
+			Origin:   segments[i].Departure,
+			Segments: []int{i},
+		}
+	}
+
+	current.Destination = segments[len(segments)-1].Arrival
+	journeys = append(journeys, current)
+
+	itinerary.Trip = &model.Trip{
+		Type:     classify(journeys),
+		Journeys: journeys,
+	}
+}
+
+// Times returns local departure and arrival of segment. Segments without block time arrive at their arrival time
+// after departure, times are zero if segment has no date or time.
+func Times(segment model.Segment, registry *airport.Registry) (departure, arrival time.Time) {
+	s := place(&segment, registry)
+	if s.departure.IsZero() || !s.arrival.IsZero() || segment.ArrivalTime == "" {
+		return s.departure, s.arrival
+	}
+
+	location := s.departure.Location()
+	if a, found := registry.IATA(segment.Arrival); found && a.Tz != "" {
+		if l, err := time.LoadLocation(a.Tz); err == nil {
+			location = l
+		}
+	}
+
+	arrival, err := time.ParseInLocation("2006-01-02 15:04", segment.Date+" "+segment.ArrivalTime, location)
+	if err != nil {
+		return s.departure, time.Time{}
+	}
+
+	// flights arriving before they depart land on a later date
+	for days := 0; !arrival.After(s.departure) && days < 3; days++ {
+		arrival = arrival.AddDate(0, 0, 1)
+	}
+
+	return s.departure, arrival
+}
+
+// place resolves local departure and arrival of segment, it marks segments arriving on a later date
+func place(segment *model.Segment, registry *airport.Registry) (s schedule) {
+	if segment.Date == "" || segment.DepartureTime == "" {
+		return
+	}
+
+	departure, found := registry.IATA(segment.Departure)
+	if !found {
+		return
+	}
+
+	location, err := time.LoadLocation(departure.Tz)
+	if err != nil {
+		location = time.UTC
+	}
+
+	s.departure, err = time.ParseInLocation("2006-01-02 15:04", segment.Date+" "+segment.DepartureTime, location)
+	if err != nil {
+		return schedule{}
+	}
+
+	if segment.BlockMinutes == 0 {
+		return
+	}
+	s.arrival = s.departure.Add(time.Duration(segment.BlockMinutes) * time.Minute)
+
+	if arrival, found := registry.IATA(segment.Arrival); found {
+		if location, err = time.LoadLocation(arrival.Tz); err == nil {
+			s.arrival = s.arrival.In(location)
+		}
+	}
+
+	segment.Overnight = !sameDate(s.departure, s.arrival)
+
+	return
+}
+
+// connect decides if next segment continues journey from the same airport within connection window.
+// Segment flying back to where journey started is a return, however short the stay was.
+func connect(journey model.Journey, previous, next model.Segment, arrived, departing schedule, registry *airport.Registry) (connection model.Connection, connected bool) {
+	if !sameAirport(previous.Arrival, next.Departure, registry) || sameAirport(next.Arrival, journey.Origin, registry) {
+		return
+	}
+
+	connection.Airport = previous.Arrival
+
+	// without times the only hint is that next segment does not fly back
+	if arrived.arrival.IsZero() || departing.departure.IsZero() {
+		connected = true
+		return
+	}
+
+	ground := departing.departure.Sub(arrived.arrival)
+	if ground < MinConnection || ground > MaxConnection {
+		return
+	}
+
+	connection.LayoverMinutes = int(ground.Minutes())
+	connection.Overnight = !sameDate(arrived.arrival, departing.departure.In(arrived.arrival.Location()))
+	connected = true
+
+	return
+}
+
+// classify tells trip type from its journeys. Airports are compared exactly, flying out of London Heathrow
+// and back to London Gatwick is an open-jaw, not a round-trip.
+func classify(journeys []model.Journey) string {
+	first, last := journeys[0], journeys[len(journeys)-1]
+
+	switch len(journeys) {
+	case 1:
+		if first.Origin == first.Destination {
+			return RoundTrip
+		}
+		return OneWay
+
+	case 2:
+		returns := last.Destination == first.Origin
+		continues := last.Origin == first.Destination
+
+		// open-jaw either comes back to a different airport than it left from,
+		// or flies back from a different airport than it arrived to
+		switch {
+		case returns && continues:
+			return RoundTrip
+		case returns || continues:
+			return OpenJaw
+		}
+	}
+
+	return MultiCity
+}
+
+// sameAirport compares airports of connections, airports of the same metropolitan area are considered the same
+func sameAirport(a, b string, registry *airport.Registry) bool {
+	if a == b {
+		return true
+	}
+
+	metroA, found := registry.MetroOf(a)
+	if !found {
+		metroA, found = registry.Metro(a)
+	}
+	if !found {
+		return false
+	}
+
+	metroB, found := registry.MetroOf(b)
+	if !found {
+		metroB, found = registry.Metro(b)
+	}
+
+	return found && metroA == metroB
+}
+
+func sameDate(a, b time.Time) bool {
+	y1, m1, d1 := a.Date()
+	y2, m2, d2 := b.Date()
+
+	return y1 == y2 && m1 == m2 && d1 == d2
+}
@@ -0,0 +1,40 @@
+package webhook
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+type WebhookConfig struct {
+	// Secret signs bodies of callbacks, callbacks are refused without it
+	Secret string
+	// MaxAttempts of a delivery before it is given up
+	MaxAttempts int
+	// Backoff before the first retry, it doubles with every attempt
+	Backoff time.Duration
+	// Timeout of a delivery attempt
+	Timeout time.Duration
+}
+
+var Config WebhookConfig
+
+func init() {
+
+	Config = WebhookConfig{
+		Secret:      os.Getenv("WEBHOOK_SECRET"),
+		MaxAttempts: 5,
+		Backoff:     10 * time.Second,
+		Timeout:     10 * time.Second,
+	}
+
+	if attempts, err := strconv.Atoi(os.Getenv("WEBHOOK_MAX_ATTEMPTS")); err == nil && attempts > 0 {
+		Config.MaxAttempts = attempts
+	}
+	if backoff, err := time.ParseDuration(os.Getenv("WEBHOOK_BACKOFF")); err == nil && backoff > 0 {
+		Config.Backoff = backoff
+	}
+	if timeout, err := time.ParseDuration(os.Getenv("WEBHOOK_TIMEOUT")); err == nil && timeout > 0 {
+		Config.Timeout = timeout
+	}
+}
@@ -0,0 +1,185 @@
+// Package webhook posts signed callbacks, receivers verify the body with HMAC-SHA256 of the shared secret
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"trikliq-airport-finder/pkg/crypto"
+)
+
+const (
+	// SignatureHeader is signature of body as sha256=<hex>, the way most webhooks sign
+	SignatureHeader = "X-Signature-256"
+	DeliveryHeader  = "X-Delivery-ID"
+	EventHeader     = "X-Event"
+
+	userAgent = "trikliq-airport-finder"
+	// maxBackoff between attempts of a delivery
+	maxBackoff = time.Hour
+	// MinSecret is the shortest secret callbacks are signed with, guessable secrets would let anyone sign
+	MinSecret = 32
+)
+
+var (
+	// ErrDisabled is returned for callbacks when no secret of at least MinSecret characters is configured
+	ErrDisabled = errors.New("callbacks are not enabled")
+	// ErrURL is returned for callback URLs which are not absolute http or https URLs
+	ErrURL = errors.New("callback must be an absolute http or https URL")
+	// ErrAddress is returned for callbacks to loopback, private and link-local addresses
+	ErrAddress = errors.New("callback must be a public address")
+)
+
+//lint:ignore GLOBAL this is okay
+var (
+	// client connects to public addresses only, whatever the name of callback resolves to when it is posted.
+	// Redirects are not followed, they could lead anywhere.
+	client = &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: 5 * time.Second,
+				Control: control,
+			}).DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     time.Minute,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	// shared is the address space of carrier-grade NAT, it is not routed publicly either
+	shared = net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+	// random is jitter of backoff, it differs between processes
+	random   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	randomMu sync.Mutex
+)
+
+// Delivery is an attempt to post a callback
+type Delivery struct {
+	ID          string     `json:"id"`
+	Event       string     `json:"event"`
+	URL         string     `json:"url"`
+	Attempt     int        `json:"attempt"`
+	StatusCode  int        `json:"statusCode,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Succeeded   bool       `json:"succeeded"`
+	Duration    int64      `json:"durationMs"`
+	DeliveredAt time.Time  `json:"deliveredAt"`
+	RetryAt     *time.Time `json:"retryAt,omitempty"`
+}
+
+// Validate checks callback URL, and that callbacks can be signed at all. Names are checked once they are
+// resolved, when callback is posted.
+func Validate(callback string) error {
+	if len(Config.Secret) < MinSecret {
+		return ErrDisabled
+	}
+
+	u, err := url.Parse(callback)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrURL
+	}
+	if ip := net.ParseIP(u.Hostname()); (ip != nil && !public(ip)) || strings.EqualFold(u.Hostname(), "localhost") {
+		return ErrAddress
+	}
+
+	return nil
+}
+
+// control refuses connections to addresses which are not public, it runs after names are resolved
+func control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !public(ip) {
+		return fmt.Errorf("%w: %s", ErrAddress, host)
+	}
+
+	return nil
+}
+
+// public reports if ip is a public unicast address
+func public(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !shared.Contains(ip)
+}
+
+// Sign returns signature of body for SignatureHeader
+func Sign(body []byte) string {
+	return "sha256=" + crypto.HMAC([]byte(Config.Secret), body)
+}
+
+// Send posts body to callback once, responses other than 2xx fail the attempt
+func Send(callback, event string, attempt int, body []byte) (delivery Delivery) {
+	id, _ := crypto.UUID()
+	delivery = Delivery{
+		ID:          id,
+		Event:       event,
+		URL:         callback,
+		Attempt:     attempt,
+		DeliveredAt: time.Now().UTC(),
+	}
+
+	defer func(start time.Time) {
+		delivery.Duration = time.Since(start).Milliseconds()
+	}(time.Now())
+
+	ctx, cancel := context.WithTimeout(context.Background(), Config.Timeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, "POST", callback, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", userAgent)
+	request.Header.Set(SignatureHeader, Sign(body))
+	request.Header.Set(DeliveryHeader, id)
+	request.Header.Set(EventHeader, event)
+
+	response, err := client.Do(request)
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	defer response.Body.Close()
+
+	delivery.StatusCode = response.StatusCode
+	delivery.Succeeded = response.StatusCode >= 200 && response.StatusCode < 300
+	if !delivery.Succeeded {
+		delivery.Error = fmt.Sprintf("callback responded %s", response.Status)
+	}
+
+	return delivery
+}
+
+// Backoff returns wait before the next attempt. It doubles with every attempt, half of it is random so that
+// callbacks failing together are not retried together.
+func Backoff(attempt int) time.Duration {
+	wait := Config.Backoff
+	for i := 1; i < attempt && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+
+	randomMu.Lock()
+	defer randomMu.Unlock()
+
+	return wait/2 + time.Duration(random.Int63n(int64(wait/2)+1))
+}